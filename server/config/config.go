@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"maps"
 	"os"
 	"path/filepath"
@@ -10,8 +11,10 @@ import (
 
 	Handler "stepkeys/server/handler"
 	Log "stepkeys/server/logging"
+	OBSClient "stepkeys/server/obs"
 	OS "stepkeys/server/os"
 	. "stepkeys/server/pedal"
+	PluginHost "stepkeys/server/plugin"
 )
 
 // Key-value map of pedal IDs to actions
@@ -41,6 +44,27 @@ type AppConfig struct {
 	WebPort     int  `json:"webPort"`
 	StartOnBoot bool `json:"startOnBoot"`
 	Enabled     bool `json:"enabled"`
+
+	// Global safeguard against runaway pedal actions, 0 means unlimited
+	MaxActionsPerSecond int `json:"maxActionsPerSecond"`
+
+	// External action plugins, started and supervised by StepKeys
+	Plugins []PluginHost.Config `json:"plugins,omitempty"`
+
+	// obs-websocket connection used by obs pedals
+	OBS *OBSClient.Config `json:"obs,omitempty"`
+
+	// OSC messages that press virtual pedals
+	OSCInput *OSCInput `json:"oscInput,omitempty"`
+
+	// The active profile, empty if pedals.json is used
+	// Set by nextProfile pedals
+	Profile string `json:"profile,omitempty"`
+
+	// How input is injected: robotgo (default) or uinput (Linux only)
+	InputBackend string `json:"inputBackend,omitempty"`
+
+	// Plugins, OBS, OSCInput, InputBackend and WebPort are only read on startup
 }
 
 var (
@@ -114,9 +138,42 @@ func LoadConfig() {
 		}
 	}
 
+	// Continue with the profile that was active on exit
+	if appConfig.Profile != "" {
+		if newMap, err := readProfile(appConfig.Profile); err == nil {
+			pedalMap = newMap
+			Log.WriteToLogFile(fmt.Sprintf("Using profile %q.", appConfig.Profile))
+		} else {
+			Log.WriteToLogFile(fmt.Sprintf("Failed to load profile %q, using pedals.json: %v", appConfig.Profile, err))
+			appConfig.Profile = ""
+			saveAppConfig()
+		}
+	}
+
+	// Select the input backend before any input is sent
+	if err := Handler.SetInputBackend(appConfig.InputBackend); err != nil {
+		Log.WriteToLogFile("Failed to set up the input backend, using robotgo: " + err.Error())
+	}
+
 	// Sync handler copies
 	Handler.UpdatePedalMap(GetPedalMap())
 	Handler.UpdateEnabled(IsEnabled())
+	Handler.UpdateRateLimit(appConfig.MaxActionsPerSecond)
+
+	registerMetaCallbacks()
+
+	// Start plugins once the handler is ready for their calls
+	PluginHost.SetCallbacks(PluginHost.Callbacks{
+		TapKeys:    Handler.TapKeys,
+		TypeText:   Handler.TypeText,
+		IsEnabled:  IsEnabled,
+		SetEnabled: setEnabled,
+	})
+	PluginHost.Start(appConfig.Plugins)
+
+	OBSClient.Start(appConfig.OBS)
+
+	startOSCInput(appConfig.OSCInput)
 }
 
 // Save config data to file
@@ -139,6 +196,9 @@ func IsEnabled() bool {
 
 // Flips the enabled state
 func ToggleEnabled() {
+	// Changing the state ends a running pause (Pause starts its timer afterwards)
+	cancelPause()
+
 	appConfigMu.Lock()
 
 	// Pre-enable checks
@@ -167,6 +227,13 @@ func ToggleEnabled() {
 	BroadcastSetting("enabled", IsEnabled())
 }
 
+// Helper: set the enabled state, used by plugins
+func setEnabled(state bool) {
+	if IsEnabled() != state {
+		ToggleEnabled()
+	}
+}
+
 // Returns if start on boot is enabled
 // Used by the tray menu
 func IsStartOnBootEnabled() bool {
@@ -224,31 +291,19 @@ func GetWebPort() int {
 // Sets the pedal map
 // Used by the API to update the pedal configuration
 func SetPedalMap(newMap PedalMap) {
-	pedalMapMu.Lock()
-	defer pedalMapMu.Unlock()
-	pedalMap = newMap
-
-	// Update the pedal map copy in the handler package
-	Handler.UpdatePedalMap(newMap)
-
-	// Notify websocket clients
-	NotifyPedalMapUpdate()
+	applyPedalMap(newMap)
 
-	data, err := json.MarshalIndent(pedalMap, "", "  ")
+	data, err := json.MarshalIndent(newMap, "", "  ")
 	if err != nil {
 		Log.WriteToLogFile("Failed to encode pedal map: " + err.Error())
 		return
 	}
 
-	if err := os.WriteFile(pedalConfigFilePath, data, 0644); err != nil {
+	// Changes made while a profile is active belong to the profile, not to pedals.json
+	if err := os.WriteFile(activePedalMapPath(), data, 0644); err != nil {
 		Log.WriteToLogFile("Failed to save pedal config: " + err.Error())
 	}
 
-	// Disable StepKeys if the pedal map is now empty
-	if len(pedalMap) == 0 && IsEnabled() {
-		ToggleEnabled()
-	}
-
 	Log.WriteToLogFile("Pedal map updated and saved.")
 }
 
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	Handler "stepkeys/server/handler"
+	Log "stepkeys/server/logging"
+	OS "stepkeys/server/os"
+	. "stepkeys/server/pedal"
+)
+
+// Timer of a running pause, nil if StepKeys is not paused
+var (
+	pauseTimer *time.Timer
+	pauseMu    sync.Mutex
+)
+
+// Register the functions meta pedals call and the pedal state WebSocket
+func registerMetaCallbacks() {
+	Handler.SetMetaCallbacks(Handler.MetaCallbacks{
+		ToggleEnabled: ToggleEnabled,
+		NextProfile:   LoadNextProfile,
+		ReloadConfig:  ReloadConfigFiles,
+		PauseFor:      Pause,
+	})
+	Handler.SetStateCallback(broadcastState)
+}
+
+// Disable StepKeys and enable it again after d
+// Pausing again restarts the pause
+func Pause(d time.Duration) {
+	if IsEnabled() {
+		ToggleEnabled()
+	}
+
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+
+	// ToggleEnabled cancels running pauses, so this is the only timer
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		pauseMu.Lock()
+		if pauseTimer != timer {
+			pauseMu.Unlock()
+			return
+		}
+		pauseTimer = nil
+		pauseMu.Unlock()
+
+		Log.WriteToLogFile("Pause is over.")
+		if !IsEnabled() {
+			ToggleEnabled()
+		}
+	})
+	pauseTimer = timer
+
+	Log.WriteToLogFile(fmt.Sprintf("StepKeys paused for %s.", d))
+}
+
+// Cancel a running pause
+// Called when the enabled state is changed by other means
+func cancelPause() {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+
+	if pauseTimer != nil {
+		pauseTimer.Stop()
+		pauseTimer = nil
+	}
+}
+
+// Helper: path of the profiles directory next to the executable
+func profilesDirPath() string {
+	return filepath.Join(OS.GetExeDir(), "profiles")
+}
+
+// Helper: returns the profile names (the JSON files in the profiles directory without extension) in order
+func listProfiles() []string {
+	entries, err := os.ReadDir(profilesDirPath())
+	if err != nil {
+		return nil
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			profiles = append(profiles, name)
+		}
+	}
+	slices.Sort(profiles)
+	return profiles
+}
+
+// Switch to the profile after the current one
+// pedals.json (the base map) comes first, then the profiles in order, wrapping around
+func LoadNextProfile() {
+	profiles := append([]string{""}, listProfiles()...)
+	if len(profiles) == 1 {
+		Log.WriteToLogFile("No profiles found in " + profilesDirPath())
+		return
+	}
+
+	appConfigMu.RLock()
+	current := appConfig.Profile
+	appConfigMu.RUnlock()
+
+	// An unknown current profile (-1) continues with pedals.json
+	next := profiles[(slices.Index(profiles, current)+1)%len(profiles)]
+	if err := loadProfile(next); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to load profile %q: %v", next, err))
+	}
+}
+
+// Helper: path of a profile file
+func profilePath(name string) string {
+	return filepath.Join(profilesDirPath(), name+".json")
+}
+
+// Helper: path of the file the current pedal map is saved to, the profile file while a profile is active
+func activePedalMapPath() string {
+	appConfigMu.RLock()
+	defer appConfigMu.RUnlock()
+
+	if appConfig.Profile != "" {
+		return profilePath(appConfig.Profile)
+	}
+	return pedalConfigFilePath
+}
+
+// Helper: read the pedal map of a profile (pedals.json if the name is empty)
+// Only the profiles found in the profiles directory are accepted, so the name cannot point elsewhere
+func readProfile(name string) (PedalMap, error) {
+	if name == "" {
+		return readPedalMapFile(pedalConfigFilePath)
+	}
+	if !slices.Contains(listProfiles(), name) {
+		return nil, fmt.Errorf("no profile named %q in %s", name, profilesDirPath())
+	}
+	return readPedalMapFile(profilePath(name))
+}
+
+// Helper: switch to a profile (pedals.json if the name is empty) and remember it as the current profile
+// The pedal map is only replaced in memory, the files are left as they are
+func loadProfile(name string) error {
+	newMap, err := readProfile(name)
+	if err != nil {
+		return err
+	}
+
+	appConfigMu.Lock()
+	appConfig.Profile = name
+	saveAppConfig()
+	appConfigMu.Unlock()
+
+	applyPedalMap(newMap)
+
+	if name == "" {
+		Log.WriteToLogFile("Switched back to pedals.json.")
+	} else {
+		Log.WriteToLogFile(fmt.Sprintf("Switched to profile %q.", name))
+	}
+	return nil
+}
+
+// Helper: replace the pedal map in memory, without saving it
+func applyPedalMap(newMap PedalMap) {
+	pedalMapMu.Lock()
+	pedalMap = newMap
+	Handler.UpdatePedalMap(newMap)
+	pedalMapMu.Unlock()
+
+	NotifyPedalMapUpdate()
+
+	// Disable StepKeys if the pedal map is now empty
+	if len(newMap) == 0 && IsEnabled() {
+		ToggleEnabled()
+	}
+}
+
+// Reload the app config and the pedal map (of the active profile) from file, eg. after editing them by hand
+// The enabled state is kept, plugins, OBS, the OSC input, the input backend and the web port are only read on startup
+func ReloadConfigFiles() {
+	Log.WriteToLogFile("Reloading app and pedal config.")
+
+	appConfigMu.RLock()
+	profile := appConfig.Profile
+	appConfigMu.RUnlock()
+
+	// The app config selects the profile, so it is read first
+	if data, err := os.ReadFile(appConfigFilePath); err != nil {
+		Log.WriteToLogFile("Failed to reload app config: " + err.Error())
+	} else {
+		var newConfig AppConfig
+		if err := json.Unmarshal(data, &newConfig); err != nil {
+			Log.WriteToLogFile("Failed to reload app config: " + err.Error())
+		} else {
+			appConfigMu.Lock()
+			appConfig.MaxActionsPerSecond = newConfig.MaxActionsPerSecond
+			appConfigMu.Unlock()
+
+			Handler.UpdateRateLimit(newConfig.MaxActionsPerSecond)
+			profile = newConfig.Profile
+		}
+	}
+
+	// The profile only changes once its pedal map loaded, so the map is never saved to another file
+	newMap, err := readProfile(profile)
+	if err != nil {
+		Log.WriteToLogFile("Failed to reload pedal config, keeping the current one: " + err.Error())
+		return
+	}
+
+	appConfigMu.Lock()
+	appConfig.Profile = profile
+	appConfigMu.Unlock()
+
+	applyPedalMap(newMap)
+}
+
+// Helper: read and validate a pedal map file
+func readPedalMapFile(path string) (PedalMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var newMap PedalMap
+	if err := json.Unmarshal(data, &newMap); err != nil {
+		return nil, err
+	}
+	if err := ValidatePedalMap(newMap); err != nil {
+		return nil, err
+	}
+
+	return newMap, nil
+}
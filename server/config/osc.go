@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	Handler "stepkeys/server/handler"
+	Log "stepkeys/server/logging"
+	OSCNet "stepkeys/server/osc"
+)
+
+// OSC input listener in config.json
+type OSCInput struct {
+	// Listen is the UDP address OSC messages are received on, eg. :9000
+	Listen string `json:"listen"`
+
+	// Pedals maps OSC addresses to the virtual pedal IDs they press (0-127)
+	Pedals map[string]int `json:"pedals"`
+}
+
+// Start the OSC input listener, if configured
+// Only read on startup
+func startOSCInput(input *OSCInput) {
+	if input == nil {
+		return
+	}
+
+	_, err := OSCNet.Listen(input.Listen, func(msg OSCNet.Message) {
+		pedalID, ok := input.Pedals[msg.Address]
+		if !ok {
+			return
+		}
+
+		// Messages without a state (eg. a trigger) press and release the pedal
+		var err error
+		if pressed, ok := oscPressed(msg); ok {
+			err = Handler.VirtualPedalEvent(pedalID, pressed)
+		} else if err = Handler.VirtualPedalEvent(pedalID, true); err == nil {
+			err = Handler.VirtualPedalEvent(pedalID, false)
+		}
+		if err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("OSC message %s ignored: %v", msg.Address, err))
+		}
+	}, func(err error) {
+		Log.WriteToLogFile("Invalid OSC packet received: " + err.Error())
+	})
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to start the OSC listener on %s: %v", input.Listen, err))
+		return
+	}
+
+	addresses := slices.Sorted(maps.Keys(input.Pedals))
+	Log.WriteToLogFile(fmt.Sprintf("OSC listener started on %s (%s).", input.Listen, strings.Join(addresses, ", ")))
+}
+
+// Helper: the pedal state of an OSC message from its first argument
+// Non-zero numbers and true press the pedal, zero and false release it (eg. 1.0 and 0.0 sent by a button)
+func oscPressed(msg OSCNet.Message) (bool, bool) {
+	if len(msg.Args) == 0 {
+		return false, false
+	}
+
+	switch v := msg.Args[0].(type) {
+	case int32:
+		return v != 0, true
+	case int64:
+		return v != 0, true
+	case float32:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	case bool:
+		return v, true
+	}
+	return false, false
+}
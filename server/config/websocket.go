@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
@@ -11,88 +12,101 @@ import (
 )
 
 var (
-	settingsclients   = make(map[*websocket.Conn]bool)
-	settingsClientsMu sync.Mutex
-
-	pedalsClients   = make(map[*websocket.Conn]bool)
-	pedalsClientsMu sync.Mutex
+	settingsClients = newClientSet("Settings")
+	pedalsClients   = newClientSet("Pedals")
+	stateClients    = newClientSet("State")
 
 	upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool { return true },
 	}
 )
 
-// Called when enabled or startOnBoot state (may) have changed
-func BroadcastSetting(event string, value bool) {
-	settingsClientsMu.Lock()
-	defer settingsClientsMu.Unlock()
+// Connected WebSocket clients of an endpoint
+type clientSet struct {
+	name    string // used in log messages
+	clients map[*websocket.Conn]bool
+	mu      sync.Mutex
+}
 
-	msg := fmt.Sprintf(`{"event":"%s","value":%t}`, event, value)
-	for client := range settingsclients {
-		if err := client.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+func newClientSet(name string) *clientSet {
+	return &clientSet{name: name, clients: make(map[*websocket.Conn]bool)}
+}
+
+// Send a message to every client, clients that fail are dropped
+func (s *clientSet) broadcast(msg []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		if err := client.WriteMessage(websocket.TextMessage, msg); err != nil {
 			client.Close()
-			delete(settingsclients, client)
+			delete(s.clients, client)
 		}
 	}
 }
 
-// WebSocket handler for settings
-func SettingsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+// Upgrade the request and keep the connection open until the client goes away
+func (s *clientSet) serve(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		Log.WriteToLogFile("Settings WebSocket upgrade error: " + err.Error())
+		Log.WriteToLogFile(s.name + " WebSocket upgrade error: " + err.Error())
+		return
 	}
 
-	settingsClientsMu.Lock()
-	settingsclients[conn] = true
-	settingsClientsMu.Unlock()
+	s.mu.Lock()
+	s.clients[conn] = true
+	s.mu.Unlock()
 
 	// Keep the connection alive
 	for {
 		if _, _, err := conn.NextReader(); err != nil {
-			settingsClientsMu.Lock()
-			delete(settingsclients, conn)
-			settingsClientsMu.Unlock()
+			s.mu.Lock()
+			delete(s.clients, conn)
+			s.mu.Unlock()
 			conn.Close()
 			break
 		}
 	}
 }
 
+// Called when enabled or startOnBoot state (may) have changed
+func BroadcastSetting(event string, value bool) {
+	settingsClients.broadcast(fmt.Appendf(nil, `{"event":"%s","value":%t}`, event, value))
+}
+
+// WebSocket handler for settings
+func SettingsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	settingsClients.serve(w, r)
+}
+
 // Called when the pedal map is updated
 func NotifyPedalMapUpdate() {
-	pedalsClientsMu.Lock()
-	defer pedalsClientsMu.Unlock()
-
-	for client := range pedalsClients {
-		// Message doesn't matter, this acts as a change ping
-		if err := client.WriteMessage(websocket.TextMessage, []byte("1")); err != nil {
-			client.Close()
-			delete(pedalsClients, client)
-		}
-	}
+	// Message doesn't matter, this acts as a change ping
+	pedalsClients.broadcast([]byte("1"))
 }
 
 // WebSocket handler for pedal map updates
 func PedalWebSocketHandler(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	pedalsClients.serve(w, r)
+}
+
+// Pedal state change message sent to WebSocket clients
+type stateEvent struct {
+	Event string `json:"event"`
+	Pedal int    `json:"pedal"`
+	Value any    `json:"value"`
+}
+
+// Called by the handler when the runtime state of a pedal changed
+func broadcastState(event string, pedalID int, value any) {
+	msg, err := json.Marshal(stateEvent{Event: event, Pedal: pedalID, Value: value})
 	if err != nil {
-		Log.WriteToLogFile("Pedals WebSocket upgrade error: " + err.Error())
 		return
 	}
+	stateClients.broadcast(msg)
+}
 
-	pedalsClientsMu.Lock()
-	pedalsClients[conn] = true
-	pedalsClientsMu.Unlock()
-
-	// Keep the connection alive
-	for {
-		if _, _, err := conn.NextReader(); err != nil {
-			pedalsClientsMu.Lock()
-			delete(pedalsClients, conn)
-			pedalsClientsMu.Unlock()
-			conn.Close()
-			break
-		}
-	}
+// WebSocket handler for pedal state changes (toggle and sticky modifier pedals)
+func StateWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	stateClients.serve(w, r)
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	Pedal "stepkeys/server/pedal"
+	PluginHost "stepkeys/server/plugin"
+)
+
+// Oneshot behaviour helper
+// Triggers the action once, based on its mode
+func triggerAction(action Pedal.PedalAction) {
+	if action.TargetWindow != nil {
+		sendToWindow(action)
+		return
+	}
+
+	switch action.Mode {
+	case Pedal.Sequence:
+		tapKeys(action.Keys)
+	case Pedal.Combo:
+		tapCombo(action.Keys)
+	case Pedal.Click:
+		clickButton(action.Button, false)
+	case Pedal.DoubleClick:
+		clickButton(action.Button, true)
+	case Pedal.Scroll:
+		scroll(action.Direction, action.Steps)
+	case Pedal.Command:
+		runCommand(action.Command)
+	case Pedal.OpenURL:
+		openURL(action.URL)
+	case Pedal.LaunchApp:
+		launchApp(action.App, action.AppArgs)
+	case Pedal.FocusWindow:
+		focusWindow(*action.Window)
+	case Pedal.HTTP:
+		sendHTTP(action.HTTP, "press")
+	case Pedal.Text:
+		typeText(action.Text)
+	case Pedal.Paste:
+		pasteText(action.Text, action.ClipboardRestore())
+	case Pedal.Script:
+		runScript(action.Script)
+	case Pedal.Plugin:
+		PluginHost.Invoke(action.Plugin.Name, action.Plugin.Action, action.Plugin.Params)
+	case Pedal.Gamepad:
+		tapGamepadButton(action.GamepadButton)
+	case Pedal.Media:
+		controlMedia(action.Media)
+	case Pedal.OBS:
+		controlOBS(action.OBS)
+	case Pedal.OSC:
+		sendOSC(action.OSC, true)
+	case Pedal.Disable, Pedal.ReleaseAll, Pedal.NextProfile, Pedal.ReloadConfig, Pedal.PauseFor:
+		triggerMeta(action)
+	}
+}
+
+// Toggle and hold behaviour helper
+// Holds the keys, mouse or gamepad button down (or moves the pointer or a gamepad axis, or plays media) on behalf of a pedal
+func pressAction(pedalID int, action Pedal.PedalAction) {
+	switch action.Mode {
+	case Pedal.Sequence, Pedal.Combo:
+		pressKeys(pedalID, action.Keys)
+	case Pedal.Click:
+		pressButton(pedalID, action.Button)
+	case Pedal.MouseMove:
+		startMove(pedalID, action)
+	case Pedal.Gamepad:
+		pressGamepad(pedalID, action)
+	case Pedal.Media:
+		pressMedia(action.Media)
+	}
+}
+
+// Toggle and hold behaviour helper
+// Releases what pressAction held down for the pedal
+func releaseAction(pedalID int, action Pedal.PedalAction) {
+	switch action.Mode {
+	case Pedal.Sequence, Pedal.Combo:
+		releaseKeys(pedalID, action.Keys)
+	case Pedal.Click:
+		releaseButton(pedalID, action.Button)
+	case Pedal.MouseMove:
+		stopMove(pedalID)
+	case Pedal.Gamepad:
+		releaseGamepad(pedalID, action)
+	case Pedal.Media:
+		releaseMedia(action.Media)
+	}
+}
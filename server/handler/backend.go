@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Uinput "stepkeys/server/uinput"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// Input backends selectable in config.json
+const (
+	BackendRobotgo = "robotgo" // default, injects through the display server
+	BackendUinput  = "uinput"  // Linux virtual devices, works on Wayland, TTYs and without a display server
+)
+
+// Injects keyboard and mouse input
+// Key and button names are the StepKeys names, validated before they get here
+type inputBackend interface {
+	KeyTap(key string, mods []string)
+	KeyDown(key string)
+	KeyUp(key string)
+	Click(button string, double bool)
+	ButtonDown(button string)
+	ButtonUp(button string)
+	Scroll(direction string, steps int)
+	MoveRelative(dx, dy int)
+	TypeStr(text string)
+}
+
+// The active input backend
+// Only replaced on startup, before the serial listener starts
+var input inputBackend = robotgoBackend{}
+
+// Select the input backend by name, an empty name selects robotgo
+// Keeps the current backend on error
+func SetInputBackend(name string) error {
+	if name == "" {
+		name = BackendRobotgo
+	}
+
+	switch name {
+	case BackendRobotgo:
+		input = robotgoBackend{}
+	case BackendUinput:
+		backend, err := newUinputBackend()
+		if err != nil {
+			return err
+		}
+		input = backend
+	default:
+		return fmt.Errorf("unknown input backend %q (use <%s> or <%s>)", name, BackendRobotgo, BackendUinput)
+	}
+
+	Log.WriteToLogFile("Input backend: " + name)
+	return nil
+}
+
+// Input through robotgo
+type robotgoBackend struct{}
+
+// Helper: convert a StepKeys mouse button name to the name robotgo uses
+func robotgoButton(button string) string {
+	if button == "middle" {
+		return "center"
+	}
+	return button
+}
+
+func (robotgoBackend) KeyTap(key string, mods []string) { robotgo.KeyTap(key, stringToAny(mods)...) }
+func (robotgoBackend) KeyDown(key string)               { robotgo.KeyDown(key) }
+func (robotgoBackend) KeyUp(key string)                 { robotgo.KeyUp(key) }
+func (robotgoBackend) Click(button string, double bool) { robotgo.Click(robotgoButton(button), double) }
+func (robotgoBackend) ButtonDown(button string)         { robotgo.Toggle(robotgoButton(button), "down") }
+func (robotgoBackend) ButtonUp(button string)           { robotgo.Toggle(robotgoButton(button), "up") }
+func (robotgoBackend) Scroll(direction string, steps int) {
+	robotgo.ScrollDir(steps, direction)
+}
+func (robotgoBackend) MoveRelative(dx, dy int) { robotgo.MoveRelative(dx, dy) }
+func (robotgoBackend) TypeStr(text string)     { robotgo.TypeStr(text) }
+
+// Receives the input events of the uinput backend
+// A *Uinput.Device, tests record the events instead
+type eventWriter interface {
+	Emit(events ...Uinput.Event) error
+}
+
+// Input through a virtual uinput keyboard and mouse
+type uinputBackend struct {
+	device eventWriter
+
+	// Keys holding left shift down: the shift keys themselves, held capitals and taps in progress
+	// Left shift is released when the last one lets go, so a capital does not release a held shift
+	shiftHolders map[string]bool
+	shiftMu      sync.Mutex
+}
+
+// Holder of left shift while a shifted key is tapped
+const tapShiftHolder = ""
+
+// Mouse buttons of the virtual mouse
+var uinputButtons = map[string]uint16{
+	"left":   Uinput.BtnLeft,
+	"right":  Uinput.BtnRight,
+	"middle": Uinput.BtnMiddle,
+}
+
+func newUinputBackend() (*uinputBackend, error) {
+	keys := Uinput.KeyCodes()
+	for _, code := range uinputButtons {
+		keys = append(keys, code)
+	}
+	slices.Sort(keys)
+
+	device, err := Uinput.Create("StepKeys virtual input", Uinput.Capabilities{
+		Keys: keys,
+		Rels: []uint16{Uinput.RelX, Uinput.RelY, Uinput.RelWheel, Uinput.RelHWheel},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The compositor needs a moment to pick up the new device, earlier events are lost
+	time.Sleep(200 * time.Millisecond)
+
+	return &uinputBackend{device: device, shiftHolders: make(map[string]bool)}, nil
+}
+
+// Helper: emit events, logging failures
+func (b *uinputBackend) emit(events ...Uinput.Event) {
+	if err := b.device.Emit(events...); err != nil {
+		Log.WriteToLogFile("uinput write failed: " + err.Error())
+	}
+}
+
+// Helper: press or release an evdev key
+func (b *uinputBackend) key(code uint16, down bool) {
+	value := int32(0)
+	if down {
+		value = 1
+	}
+	b.emit(Uinput.Event{Type: Uinput.EvKey, Code: code, Value: value})
+}
+
+// Helper: hold left shift down on behalf of a key, pressing it for the first holder
+func (b *uinputBackend) holdShift(holder string) {
+	b.shiftMu.Lock()
+	defer b.shiftMu.Unlock()
+
+	if len(b.shiftHolders) == 0 {
+		b.key(Uinput.KeyLeftShift, true)
+	}
+	b.shiftHolders[holder] = true
+}
+
+// Helper: let go of left shift on behalf of a key, releasing it after the last holder
+func (b *uinputBackend) releaseShift(holder string) {
+	b.shiftMu.Lock()
+	defer b.shiftMu.Unlock()
+
+	if !b.shiftHolders[holder] {
+		return
+	}
+	delete(b.shiftHolders, holder)
+	if len(b.shiftHolders) == 0 {
+		b.key(Uinput.KeyLeftShift, false)
+	}
+}
+
+// Helper: tap an evdev key, holding shift if needed
+func (b *uinputBackend) tap(key Uinput.Key) {
+	if key.Shift {
+		b.holdShift(tapShiftHolder)
+	}
+	b.key(key.Code, true)
+	b.key(key.Code, false)
+	if key.Shift {
+		b.releaseShift(tapShiftHolder)
+	}
+}
+
+func (b *uinputBackend) KeyTap(key string, mods []string) {
+	for _, mod := range mods {
+		b.KeyDown(mod)
+	}
+	b.tap(Uinput.Keys[key])
+	for _, mod := range slices.Backward(mods) {
+		b.KeyUp(mod)
+	}
+}
+
+// Capital letters hold shift while down
+// Left shift is shared with them, it is only pressed and released through the shift holders
+func (b *uinputBackend) KeyDown(key string) {
+	k := Uinput.Keys[key]
+	if k.Code == Uinput.KeyLeftShift {
+		b.holdShift(key)
+		return
+	}
+	if k.Shift {
+		b.holdShift(key)
+	}
+	b.key(k.Code, true)
+}
+
+func (b *uinputBackend) KeyUp(key string) {
+	k := Uinput.Keys[key]
+	if k.Code == Uinput.KeyLeftShift {
+		b.releaseShift(key)
+		return
+	}
+	b.key(k.Code, false)
+	if k.Shift {
+		b.releaseShift(key)
+	}
+}
+
+func (b *uinputBackend) Click(button string, double bool) {
+	clicks := 1
+	if double {
+		clicks = 2
+	}
+	for range clicks {
+		b.key(uinputButtons[button], true)
+		b.key(uinputButtons[button], false)
+	}
+}
+
+func (b *uinputBackend) ButtonDown(button string) { b.key(uinputButtons[button], true) }
+func (b *uinputBackend) ButtonUp(button string)   { b.key(uinputButtons[button], false) }
+
+func (b *uinputBackend) Scroll(direction string, steps int) {
+	axis, value := Uinput.RelWheel, int32(steps)
+	switch direction {
+	case "down":
+		value = -value
+	case "left":
+		axis, value = Uinput.RelHWheel, -value
+	case "right":
+		axis = Uinput.RelHWheel
+	}
+	b.emit(Uinput.Event{Type: Uinput.EvRel, Code: axis, Value: value})
+}
+
+func (b *uinputBackend) MoveRelative(dx, dy int) {
+	b.emit(
+		Uinput.Event{Type: Uinput.EvRel, Code: Uinput.RelX, Value: int32(dx)},
+		Uinput.Event{Type: Uinput.EvRel, Code: Uinput.RelY, Value: int32(dy)},
+	)
+}
+
+// Characters missing from the US layout are skipped
+func (b *uinputBackend) TypeStr(text string) {
+	skipped := 0
+	for _, r := range text {
+		key, ok := Uinput.Runes[r]
+		if !ok {
+			skipped++
+			continue
+		}
+		b.tap(key)
+	}
+
+	if skipped > 0 {
+		Log.WriteToLogFile(fmt.Sprintf("uinput cannot type %d characters (only the US layout is supported).", skipped))
+	}
+}
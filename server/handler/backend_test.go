@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	Uinput "stepkeys/server/uinput"
+)
+
+// Records the events of a uinput backend
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Uinput.Event
+}
+
+func (r *eventRecorder) Emit(events ...Uinput.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+	return nil
+}
+
+// Helper: key events in the order they were sent, as key code and value pairs
+func (r *eventRecorder) keys() [][2]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys [][2]int
+	for _, event := range r.events {
+		if event.Type == Uinput.EvKey {
+			keys = append(keys, [2]int{int(event.Code), int(event.Value)})
+		}
+	}
+	return keys
+}
+
+const (
+	shiftCode = int(Uinput.KeyLeftShift)
+	keyA      = 30
+	keyB      = 48
+	key1      = 2
+)
+
+func TestUinputShiftHolders(t *testing.T) {
+	tests := []struct {
+		name  string
+		input func(b *uinputBackend)
+		want  [][2]int
+	}{
+		{"shifted tap", func(b *uinputBackend) {
+			b.KeyTap("A", nil)
+		}, [][2]int{{shiftCode, 1}, {keyA, 1}, {keyA, 0}, {shiftCode, 0}}},
+
+		{"shift modifier", func(b *uinputBackend) {
+			b.KeyTap("a", []string{"shift"})
+		}, [][2]int{{shiftCode, 1}, {keyA, 1}, {keyA, 0}, {shiftCode, 0}}},
+
+		{"typing keeps a held shift", func(b *uinputBackend) {
+			b.KeyDown("shift")
+			b.TypeStr("A!")
+			b.KeyUp("shift")
+		}, [][2]int{{shiftCode, 1}, {keyA, 1}, {keyA, 0}, {key1, 1}, {key1, 0}, {shiftCode, 0}}},
+
+		{"capital released before shift", func(b *uinputBackend) {
+			b.KeyDown("A")
+			b.KeyDown("shift")
+			b.KeyUp("A")
+			b.KeyTap("b", nil)
+			b.KeyUp("shift")
+		}, [][2]int{{shiftCode, 1}, {keyA, 1}, {keyA, 0}, {keyB, 1}, {keyB, 0}, {shiftCode, 0}}},
+
+		{"shift released before capital", func(b *uinputBackend) {
+			b.KeyDown("shift")
+			b.KeyDown("B")
+			b.KeyUp("shift")
+			b.KeyUp("B")
+		}, [][2]int{{shiftCode, 1}, {keyB, 1}, {keyB, 0}, {shiftCode, 0}}},
+
+		{"shift and lshift", func(b *uinputBackend) {
+			b.KeyDown("shift")
+			b.KeyDown("lshift")
+			b.KeyUp("shift")
+			b.KeyUp("shift") // released twice, counts once
+			b.KeyTap("a", nil)
+			b.KeyUp("lshift")
+		}, [][2]int{{shiftCode, 1}, {keyA, 1}, {keyA, 0}, {shiftCode, 0}}},
+
+		{"release without press", func(b *uinputBackend) {
+			b.KeyUp("shift")
+			b.KeyUp("lshift")
+		}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			b := &uinputBackend{device: recorder, shiftHolders: make(map[string]bool)}
+
+			test.input(b)
+
+			if got := recorder.keys(); !slices.Equal(got, test.want) {
+				t.Errorf("got key events %v, want %v", got, test.want)
+			}
+			if len(b.shiftHolders) != 0 {
+				t.Errorf("shift still held by %v", b.shiftHolders)
+			}
+		})
+	}
+}
+
+func TestUinputTypeStrSkipsUnknownRunes(t *testing.T) {
+	recorder := &eventRecorder{}
+	b := &uinputBackend{device: recorder, shiftHolders: make(map[string]bool)}
+
+	b.TypeStr("aé1")
+
+	want := [][2]int{{keyA, 1}, {keyA, 0}, {key1, 1}, {key1, 0}}
+	if got := recorder.keys(); !slices.Equal(got, want) {
+		t.Errorf("got key events %v, want %v", got, want)
+	}
+}
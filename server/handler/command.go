@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	OS "stepkeys/server/os"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Maximum number of stdout and stderr bytes written to the log per run
+const commandOutputLimit = 1024
+
+// Runs of a single command action
+type commandRunner struct {
+	running bool
+	pending int                // runs waiting to start after the current one
+	cancel  context.CancelFunc // kills the current run
+}
+
+// Command runners: command action -> runner
+// Keyed by the action pointer, so the same command on different pedals (or layers) runs independently
+// Guarded by its own mutex, commands run outside of stateMu
+var (
+	commands   = make(map[*Pedal.CommandAction]*commandRunner)
+	commandsMu sync.Mutex
+)
+
+// Start a command in the background, following its concurrency policy
+func runCommand(command *Pedal.CommandAction) {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+
+	runner, ok := commands[command]
+	if !ok {
+		runner = &commandRunner{}
+		commands[command] = runner
+	}
+
+	if runner.running {
+		switch command.Policy {
+		case Pedal.PolicyQueue:
+			runner.pending++
+			Log.WriteToLogFile(fmt.Sprintf("Command %q queued: still running.", command.Program))
+		case Pedal.PolicyKill:
+			runner.cancel()
+			runner.pending = 1
+			Log.WriteToLogFile(fmt.Sprintf("Command %q killed, starting a new run.", command.Program))
+		default:
+			Log.WriteToLogFile(fmt.Sprintf("Command %q skipped: still running.", command.Program))
+		}
+		return
+	}
+
+	// The first run gets its context here, so a kill press right after the start finds the cancel func
+	runner.running = true
+	ctx, cancel := runner.newRun(command)
+	go runner.work(ctx, cancel, command)
+}
+
+// Helper: create the context of the next run
+// Called with commandsMu held
+func (r *commandRunner) newRun(command *Pedal.CommandAction) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), command.Timeout())
+	r.cancel = cancel
+	return ctx, cancel
+}
+
+// Execute the first run, then the pending runs one after another
+func (r *commandRunner) work(ctx context.Context, cancel context.CancelFunc, command *Pedal.CommandAction) {
+	for {
+		executeCommand(ctx, command)
+		cancel()
+
+		commandsMu.Lock()
+		if r.pending == 0 {
+			r.running = false
+			delete(commands, command)
+			commandsMu.Unlock()
+			return
+		}
+		r.pending--
+		ctx, cancel = r.newRun(command)
+		commandsMu.Unlock()
+	}
+}
+
+// Run the program and log the result
+func executeCommand(ctx context.Context, command *Pedal.CommandAction) {
+	var cmd *exec.Cmd
+	switch {
+	case !command.Shell:
+		cmd = exec.CommandContext(ctx, command.Program, command.Args...)
+	case runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command.Program)
+	default:
+		cmd = exec.CommandContext(ctx, "sh", "-c", command.Program)
+	}
+
+	cmd.Dir = command.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = OS.GetExeDir()
+	}
+
+	cmd.Env = os.Environ()
+	for key, value := range command.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	stdout := &limitedBuffer{limit: commandOutputLimit}
+	stderr := &limitedBuffer{limit: commandOutputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// Do not wait forever for child processes that keep the output open
+	cmd.WaitDelay = time.Second
+
+	Log.WriteToLogFile(fmt.Sprintf("Running command %q.", command.Program))
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var result string
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result = fmt.Sprintf("timed out after %s", command.Timeout())
+	case errors.Is(ctx.Err(), context.Canceled):
+		result = "killed"
+	case errors.As(err, &exitErr):
+		result = fmt.Sprintf("exited with code %d in %s", exitErr.ExitCode(), elapsed)
+	case err != nil:
+		result = "failed to start: " + err.Error()
+	default:
+		result = fmt.Sprintf("exited with code 0 in %s", elapsed)
+	}
+
+	msg := fmt.Sprintf("Command %q %s.", command.Program, result)
+	if out := stdout.String(); out != "" {
+		msg += "\nstdout: " + out
+	}
+	if out := stderr.String(); out != "" {
+		msg += "\nstderr: " + out
+	}
+	Log.WriteToLogFile(msg)
+}
+
+// Output buffer that keeps the first limit bytes and drops the rest
+type limitedBuffer struct {
+	buf       strings.Builder
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := max(b.limit-b.buf.Len(), 0)
+	if len(p) > room {
+		b.truncated = true
+	}
+	b.buf.Write(p[:min(len(p), room)])
+
+	// Report everything as written, so the program is not interrupted
+	return len(p), nil
+}
+
+// Returns the trimmed output, marked if it was truncated
+func (b *limitedBuffer) String() string {
+	out := strings.TrimSpace(b.buf.String())
+	if b.truncated {
+		out += " [truncated]"
+	}
+	return out
+}
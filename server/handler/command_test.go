@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// Helper: wait until the command has no runner left
+func waitForCommand(t *testing.T, command *Pedal.CommandAction) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		commandsMu.Lock()
+		_, running := commands[command]
+		commandsMu.Unlock()
+		if !running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommandPolicies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	policies := []struct {
+		policy           Pedal.CommandPolicy
+		minRuns, maxRuns int
+	}{
+		{Pedal.PolicySkip, 1, 1},
+		{Pedal.PolicyQueue, 3, 3},
+		{Pedal.PolicyKill, 1, 2}, // The first run may be killed before it wrote, one new run follows
+	}
+	for _, p := range policies {
+		out := filepath.Join(t.TempDir(), "runs")
+		command := &Pedal.CommandAction{
+			Program: "echo run >> " + out + "; sleep 0.2",
+			Shell:   true,
+			Policy:  p.policy,
+		}
+
+		// Presses right after the first one, before its process started
+		for range 3 {
+			runCommand(command)
+		}
+		waitForCommand(t, command)
+
+		data, _ := os.ReadFile(out)
+		if runs := strings.Count(string(data), "run"); runs < p.minRuns || runs > p.maxRuns {
+			t.Errorf("policy %s: got %d runs, want %d to %d", p.policy, runs, p.minRuns, p.maxRuns)
+		}
+	}
+}
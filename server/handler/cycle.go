@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Progress of a cycle pedal
+type cycleState struct {
+	next       int           // index of the action the next press triggers
+	lastPress  time.Time     // used by the automatic reset
+	resetAfter time.Duration // 0 if the cycle is never reset automatically
+}
+
+// Cycle pedal progress: pedalID -> state
+// Guarded by stateMu
+var cycles = make(map[int]*cycleState)
+
+// Returns the index of the next action, taking the automatic reset into account
+func (c *cycleState) nextIndex(now time.Time) int {
+	if c.resetAfter > 0 && now.Sub(c.lastPress) > c.resetAfter {
+		return 0
+	}
+	return c.next
+}
+
+// Trigger the next action of a cycle pedal and advance it
+func advanceCycle(pedalID int, action Pedal.PedalAction) {
+	now := time.Now()
+
+	state, ok := cycles[pedalID]
+	if !ok {
+		state = &cycleState{}
+		cycles[pedalID] = state
+	}
+	state.resetAfter = time.Duration(action.CycleResetMs) * time.Millisecond
+
+	index := state.nextIndex(now)
+	if index >= len(action.Actions) {
+		index = 0 // the action list got shorter (eg. the pedal is on another layer now)
+	}
+
+	Log.WriteToLogFile(fmt.Sprintf("Pedal %d cycle action %d/%d", pedalID, index+1, len(action.Actions)))
+	triggerAction(action.Actions[index])
+
+	state.next = (index + 1) % len(action.Actions)
+	state.lastPress = now
+}
+
+// Returns the index of the next action of every cycle pedal that was pressed: pedalID -> index
+// Used by the API
+func GetCycleIndexes() map[string]int {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	now := time.Now()
+	indexes := make(map[string]int, len(cycles))
+	for pedalID, state := range cycles {
+		indexes[strconv.Itoa(pedalID)] = state.nextIndex(now)
+	}
+
+	return indexes
+}
+
+// Returned by ResetCycle for pedals without a cycle action
+var ErrNotCyclePedal = errors.New("not a cycle pedal")
+
+// Restart a cycle pedal from its first action
+// The pedal ID must be 0-127 and the pedal needs a cycle action (on the base layer or a layer override)
+// Used by the API
+func ResetCycle(pedalID string) error {
+	id, err := strconv.Atoi(pedalID)
+	if err != nil || id < 0 || id > 127 || strconv.Itoa(id) != pedalID {
+		return fmt.Errorf("invalid pedal ID %q (use 0-127)", pedalID)
+	}
+	if !hasCycleAction(readPedalMap()[pedalID]) {
+		return fmt.Errorf("pedal %d: %w", id, ErrNotCyclePedal)
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	delete(cycles, id)
+	Log.WriteToLogFile(fmt.Sprintf("Cycle pedal %d was reset.", id))
+	return nil
+}
+
+// Restart every cycle pedal from its first action
+// Used by the API
+func ResetAllCycles() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	resetCycles()
+	Log.WriteToLogFile("All cycle pedals were reset.")
+}
+
+// Helper: check if the action or one of its layer overrides is a cycle
+func hasCycleAction(action Pedal.PedalAction) bool {
+	if action.Behaviour == Pedal.Cycle {
+		return true
+	}
+	for _, layerAction := range action.Layers {
+		if layerAction.Behaviour == Pedal.Cycle {
+			return true
+		}
+	}
+	return false
+}
+
+// Forget the progress of every cycle pedal
+// Called from resetPedals
+func resetCycles() {
+	clear(cycles)
+}
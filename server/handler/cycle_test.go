@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+func TestResetCycle(t *testing.T) {
+	cycle := Pedal.PedalAction{
+		Behaviour: Pedal.Cycle,
+		Actions:   []Pedal.PedalAction{{Mode: Pedal.Sequence, Keys: []string{"a"}, Behaviour: Pedal.Oneshot}},
+	}
+	oneshot := Pedal.PedalAction{Mode: Pedal.Sequence, Keys: []string{"b"}, Behaviour: Pedal.Oneshot}
+	layered := oneshot
+	layered.Layers = map[string]Pedal.PedalAction{"nav": cycle}
+
+	UpdatePedalMap(Pedal.PedalMap{"1": cycle, "2": oneshot, "3": layered})
+	t.Cleanup(func() { UpdatePedalMap(make(Pedal.PedalMap)) })
+
+	// Cycles on the base layer and on a layer override
+	for _, id := range []int{1, 3} {
+		stateMu.Lock()
+		cycles[1], cycles[3] = &cycleState{next: 1}, &cycleState{next: 1}
+		stateMu.Unlock()
+
+		if err := ResetCycle(strconv.Itoa(id)); err != nil {
+			t.Fatalf("pedal %d: %v", id, err)
+		}
+
+		stateMu.Lock()
+		_, kept := cycles[id]
+		remaining := len(cycles)
+		stateMu.Unlock()
+		if kept || remaining != 1 {
+			t.Errorf("pedal %d: reset the wrong cycles (%d left)", id, remaining)
+		}
+	}
+
+	// Pedals without a cycle action
+	for _, pedalID := range []string{"2", "4", "127"} {
+		if err := ResetCycle(pedalID); !errors.Is(err, ErrNotCyclePedal) {
+			t.Errorf("pedal %s: got error %v, want ErrNotCyclePedal", pedalID, err)
+		}
+	}
+
+	// Invalid pedal IDs
+	for _, pedalID := range []string{"", "-1", "128", "01", "+1", "one"} {
+		err := ResetCycle(pedalID)
+		if err == nil || errors.Is(err, ErrNotCyclePedal) {
+			t.Errorf("pedal %q: got error %v, want an invalid ID error", pedalID, err)
+		}
+	}
+
+	stateMu.Lock()
+	cycles[1] = &cycleState{next: 1}
+	stateMu.Unlock()
+	ResetAllCycles()
+	if indexes := GetCycleIndexes(); len(indexes) != 0 {
+		t.Errorf("cycles left after ResetAllCycles: %v", indexes)
+	}
+}
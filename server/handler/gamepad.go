@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+	Uinput "stepkeys/server/uinput"
+)
+
+// Virtual gamepad, created when a pedal map with gamepad pedals is loaded
+// A failed creation is not retried, gamepad pedals do nothing until restart
+var (
+	gamepad    *Uinput.Device
+	gamepadErr error
+	gamepadMu  sync.Mutex // guards the two above, locked after stateMu
+
+	gamepadCreateMu sync.Mutex // one creation attempt at a time, never held with stateMu
+)
+
+// Virtual gamepad state, guarded by stateMu
+var (
+	// Held gamepad buttons: button -> set of pedal IDs holding it, works like keyOwners
+	gamepadButtonOwners = make(map[string]map[int]bool)
+
+	// Moved gamepad axes: axis -> pedal ID, the last pedal wins
+	gamepadAxisOwners = make(map[string]int)
+)
+
+// Create the virtual gamepad if the pedal map uses it and it was not tried yet
+// Run in the background by UpdatePedalMap, gamepad presses before the device is ready do nothing
+func prepareGamepad(newMap Pedal.PedalMap) {
+	if !Pedal.UsesGamepad(newMap) {
+		return
+	}
+
+	gamepadCreateMu.Lock()
+	defer gamepadCreateMu.Unlock()
+
+	gamepadMu.Lock()
+	tried := gamepad != nil || gamepadErr != nil
+	gamepadMu.Unlock()
+	if tried {
+		return
+	}
+
+	device, err := Uinput.Create("StepKeys virtual gamepad", Uinput.GamepadCapabilities())
+	if err != nil {
+		gamepadMu.Lock()
+		gamepadErr = err
+		gamepadMu.Unlock()
+		Log.WriteToLogFile("Virtual gamepad unavailable, gamepad pedals are ignored: " + err.Error())
+		return
+	}
+
+	// Games need a moment to pick up the new device, earlier events are lost
+	time.Sleep(200 * time.Millisecond)
+
+	gamepadMu.Lock()
+	gamepad = device
+	gamepadMu.Unlock()
+	Log.WriteToLogFile("Virtual gamepad created.")
+}
+
+// Helper: send a gamepad event, logging failures
+// Does nothing without a virtual gamepad, the reason was logged when it was created
+func emitGamepad(evType, code uint16, value int32) {
+	gamepadMu.Lock()
+	device := gamepad
+	gamepadMu.Unlock()
+	if device == nil {
+		return
+	}
+	if err := device.Emit(Uinput.Event{Type: evType, Code: code, Value: value}); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Gamepad write failed: %v", err))
+	}
+}
+
+// Press and release a gamepad button
+// A held button is not tapped, as the tap would release it for its owners
+func tapGamepadButton(button string) {
+	if len(gamepadButtonOwners[button]) > 0 {
+		return
+	}
+	code := Uinput.GamepadButtons[button]
+	emitGamepad(Uinput.EvKey, code, 1)
+	emitGamepad(Uinput.EvKey, code, 0)
+}
+
+// Hold a gamepad button down or move an axis on behalf of a pedal
+func pressGamepad(pedalID int, action Pedal.PedalAction) {
+	if action.GamepadAxis != "" {
+		axis := Uinput.GamepadAxes[action.GamepadAxis]
+		gamepadAxisOwners[action.GamepadAxis] = pedalID
+		emitGamepad(Uinput.EvAbs, axis.Code, axis.Value(action.AxisValue))
+		return
+	}
+
+	owners, ok := gamepadButtonOwners[action.GamepadButton]
+	if !ok {
+		owners = make(map[int]bool)
+		gamepadButtonOwners[action.GamepadButton] = owners
+	}
+	if len(owners) == 0 {
+		emitGamepad(Uinput.EvKey, Uinput.GamepadButtons[action.GamepadButton], 1)
+	}
+	owners[pedalID] = true
+}
+
+// Release what pressGamepad held for the pedal
+// An axis returns to rest, unless another pedal moved it since
+func releaseGamepad(pedalID int, action Pedal.PedalAction) {
+	if action.GamepadAxis != "" {
+		if owner, ok := gamepadAxisOwners[action.GamepadAxis]; ok && owner == pedalID {
+			delete(gamepadAxisOwners, action.GamepadAxis)
+			axis := Uinput.GamepadAxes[action.GamepadAxis]
+			emitGamepad(Uinput.EvAbs, axis.Code, axis.Value(0))
+		}
+		return
+	}
+
+	owners := gamepadButtonOwners[action.GamepadButton]
+	if !owners[pedalID] {
+		return
+	}
+	delete(owners, pedalID)
+	if len(owners) == 0 {
+		emitGamepad(Uinput.EvKey, Uinput.GamepadButtons[action.GamepadButton], 0)
+		delete(gamepadButtonOwners, action.GamepadButton)
+	}
+}
+
+// Release every gamepad button and return every axis to rest
+// Called from resetPedals
+func resetGamepad() {
+	for button := range gamepadButtonOwners {
+		emitGamepad(Uinput.EvKey, Uinput.GamepadButtons[button], 0)
+	}
+	clear(gamepadButtonOwners)
+
+	for name := range gamepadAxisOwners {
+		axis := Uinput.GamepadAxes[name]
+		emitGamepad(Uinput.EvAbs, axis.Code, axis.Value(0))
+	}
+	clear(gamepadAxisOwners)
+}
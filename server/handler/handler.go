@@ -5,20 +5,29 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-vgo/robotgo"
 	"go.bug.st/serial"
 
 	Log "stepkeys/server/logging"
 	Pedal "stepkeys/server/pedal"
+	PluginHost "stepkeys/server/plugin"
 )
 
 // Pedal state map: pedalID -> pressed: true, released: false
-// Only has effect for toggle and hold (behaviour) pedals
+// Only has effect for toggle, hold and pressRelease (behaviour) pedals
 var pedalState = make(map[int]bool)
 
-// Currently pressed keyboard keys map: key -> pressed/released
-// Used to avoid repeated key down events and to reset state on disable or map change
-var keysDown = make(map[string]bool)
+// Guards the runtime state of the pedals:
+//   - pedalState (above)
+//   - held keys, mouse buttons and gamepad buttons and axes: keyOwners, buttonOwners, gamepadButtonOwners, gamepadAxisOwners
+//   - layers and engaged actions: toggledLayer, oneshotLayer, momentaryLayers, engagedActions
+//   - leader sequences: leaderPedal, leaderAction, leaderPresses, leaderTimer, leaderGen, capturedPedals
+//   - cycles, stickies, repeaters
+//   - mouse movement: movers, motionStop, remX, remY
+//   - rate limiting: lastActions, recentActions, maxPerSecond
+//
+// The running scripts, commands, paste timer and virtual gamepad have their own mutexes, locked after this one
+// Never call readPedalMap or readEnabled while holding it
+var stateMu sync.Mutex
 
 // Local copy of the pedal map and enabled state
 var (
@@ -41,12 +50,15 @@ func readPedalMap() Pedal.PedalMap {
 // Sync the local pedal map with the config pedal map
 func UpdatePedalMap(newMap Pedal.PedalMap) {
 	pedalMapMu.Lock()
-	defer pedalMapMu.Unlock()
-
 	pedalMap = newMap
 
 	// Reset pedals to avoid stuck keys and inconsistent state
 	resetPedals()
+	pedalMapMu.Unlock()
+
+	// Gamepad pedals need the virtual gamepad before their first press
+	// Created in the background, callers may hold config locks and creation takes a while
+	go prepareGamepad(newMap)
 }
 
 // Read the local enabled state
@@ -71,119 +83,199 @@ func UpdateEnabled(state bool) {
 // Reset all pedals and release any pressed keys
 // When StepKeys is disabled or the pedal map is changed this should be called
 func resetPedals() {
-	for key, pressed := range keysDown {
-		if pressed {
-			robotgo.KeyUp(key)
-			keysDown[key] = false
-		}
-	}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	resetSequence()
+	resetToggles()
+	resetLayers()
+	resetRepeats()
+	resetCycles()
+	resetStickies()
+	resetMotion()
+	resetScripts()
+
+	releaseAllKeys()
+	releaseAllButtons()
+	resetGamepad()
 
 	for pedalID := range pedalState {
 		pedalState[pedalID] = false
 	}
 }
 
-// Helper: convert []string to []interface{} (array of any) for robotgo
-func stringToAny(s []string) []any {
-	out := make([]any, len(s))
-	for i, v := range s {
-		out[i] = v
+// Handle a raw pedal byte from Arduino
+func handlePedalByte(b byte) {
+	pedalID := int(b & 0x7F)   // Lower 7 bits: pedal ID (0-127)
+	pressed := (b & 0x80) != 0 // MSB: pressed (1) / released (0)
+
+	// Plugins get every pedal event, including unknown pedal IDs
+	PluginHost.PedalEvent(pedalID, pressed)
+
+	event := map[bool]string{true: "pressed", false: "released"}[pressed]
+	baseAction, ok := readPedalMap()[fmt.Sprintf("%d", pedalID)]
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	// A latched or held pedal keeps the action it was engaged with, even if the layer changed since
+	// Otherwise the action is looked up on the active layer
+	action, engaged := engagedActions[pedalID]
+	if !engaged {
+		action = resolveAction(baseAction)
 	}
-	return out
-}
 
-// Press and release the keys
-func tapKeys(keys []string) {
-	for _, key := range keys {
-		if !keysDown[key] {
-			robotgo.KeyTap(key)
-		}
+	// Presses that belong to an active leader sequence are not handled as regular pedal events
+	if captureSequenceInput(pedalID, pressed, ok && action.Behaviour == Pedal.Leader) {
+		return
 	}
-}
 
-// Press the keys down and do not release them
-func pressKeys(keys []string) {
-	for _, key := range keys {
-		if !keysDown[key] {
-			robotgo.KeyDown(key)
-			keysDown[key] = true
-		}
+	// Only handle pedal IDs defined in the config
+	if !ok {
+		Log.WriteToLogFile(fmt.Sprintf("Received unknown pedal ID (%s): %d", event, pedalID))
+		return
 	}
-}
 
-// Release the keys
-func releaseKeys(keys []string) {
-	for _, key := range keys {
-		if keysDown[key] {
-			robotgo.KeyUp(key)
-			keysDown[key] = false
-		}
+	if layer := activeLayer(); layer != "" && !engaged {
+		Log.WriteToLogFile(fmt.Sprintf("Pedal %d %s (layer: %s)", pedalID, event, layer))
+	} else {
+		Log.WriteToLogFile(fmt.Sprintf("Pedal %d %s", pedalID, event))
 	}
-}
 
-// Oneshot behaviour helper
-func triggerKeys(action Pedal.PedalAction) {
-	switch action.Mode {
-	case Pedal.Sequence:
-		tapKeys(action.Keys)
-	case Pedal.Combo:
-		if len(action.Keys) == 0 {
+	// Cooldowns and the global limit only apply to presses that trigger an action
+	// Suppressing a press that releases held keys would leave them stuck
+	if pressed && isKeyAction(action.Behaviour) && !releasesHeldState(pedalID, action) {
+		if reason := checkRateLimit(pedalID, action); reason != "" {
+			Log.WriteToLogFile(fmt.Sprintf("Pedal %d press suppressed: %s", pedalID, reason))
 			return
 		}
-		mainKey := action.Keys[len(action.Keys)-1]
-		mods := action.Keys[:len(action.Keys)-1] // okay even if mods is empty
-		robotgo.KeyTap(mainKey, stringToAny(mods)...)
 	}
-}
 
-// Handle a raw pedal byte from Arduino
-func handlePedalByte(b byte) {
-	pedalID := int(b & 0x7F)   // Lower 7 bits: pedal ID (0-127)
-	pressed := (b & 0x80) != 0 // MSB: pressed (1) / released (0)
+	if pressed && !engaged {
+		engagedActions[pedalID] = action
+		consumeOneshotLayer(action)
+	}
 
-	event := map[bool]string{true: "pressed", false: "released"}[pressed]
-	action, ok := readPedalMap()[fmt.Sprintf("%d", pedalID)]
+	// Armed sticky modifiers apply to the next pedal action
+	if pressed && isKeyAction(action.Behaviour) {
+		withStickyModifiers(func() { handleAction(pedalID, action, pressed) })
+	} else {
+		handleAction(pedalID, action, pressed)
+	}
 
-	// Only handle pedal IDs defined in the config
-	if !ok {
-		Log.WriteToLogFile(fmt.Sprintf("Received unknown pedal ID (%s): %d", event, pedalID))
-		return
+	// Only latched pedals stay engaged after release
+	if !pressed && !pedalState[pedalID] {
+		delete(engagedActions, pedalID)
 	}
+}
 
-	Log.WriteToLogFile(fmt.Sprintf("Pedal %d %s", pedalID, event))
+// Checks if the behaviour sends keys on press
+// Leader, layer switch and sticky modifier pedals only change the state of the handler
+func isKeyAction(behaviour Pedal.PedalBehaviour) bool {
+	return behaviour != Pedal.Leader && behaviour != Pedal.StickyModifier && !Pedal.IsLayerSwitch(behaviour)
+}
 
+// Checks if a press only releases what the pedal holds (unlatching a toggle pedal)
+func releasesHeldState(pedalID int, action Pedal.PedalAction) bool {
+	return action.Behaviour == Pedal.Toggle && pedalState[pedalID]
+}
+
+// Run the behaviour of an action for a pedal event
+func handleAction(pedalID int, action Pedal.PedalAction, pressed bool) {
 	switch action.Behaviour {
 	case Pedal.Oneshot:
 		// Press event
 		if pressed {
-			triggerKeys(action)
+			triggerAction(action)
+		} else if action.Mode == Pedal.HTTP && action.HTTP.OnRelease {
+			sendHTTP(action.HTTP, "release")
+		} else if action.Mode == Pedal.OSC && action.OSC.OnRelease {
+			sendOSC(action.OSC, false)
 		}
 
-		// Release event does nothing in oneshot mode
+		// Release event does nothing else in oneshot mode
 
 	case Pedal.Toggle:
 		if pressed {
 			if pedalState[pedalID] {
 				// Pressed -> released
-				releaseKeys(action.Keys)
-				pedalState[pedalID] = false
+				releaseAction(pedalID, action)
+				setToggleState(pedalID, false)
 			} else {
 				// Released → pressed
-				pressKeys(action.Keys)
-				pedalState[pedalID] = true
+				if action.Group != "" {
+					unlatchGroup(pedalID, action.Group)
+				}
+				pressAction(pedalID, action)
+				setToggleState(pedalID, true)
 			}
 		}
 
 		// Release event does nothing in toggle mode
 
 	case Pedal.Hold:
+		// Auto-repeat taps the keys instead of holding them down
+		if action.AutoRepeat != nil {
+			if pressed {
+				triggerAction(action)
+				startRepeat(pedalID, action)
+			} else {
+				stopRepeat(pedalID)
+			}
+			pedalState[pedalID] = pressed
+			return
+		}
+
 		if pressed {
-			pressKeys(action.Keys)
+			pressAction(pedalID, action)
 			pedalState[pedalID] = true
 		} else {
-			releaseKeys(action.Keys)
+			releaseAction(pedalID, action)
 			pedalState[pedalID] = false
 		}
+
+	case Pedal.Leader:
+		if pressed {
+			startSequence(pedalID, action)
+		}
+
+		// Release event does nothing in leader mode
+
+	case Pedal.Cycle:
+		if pressed {
+			advanceCycle(pedalID, action)
+		}
+
+		// Release event does nothing in cycle mode
+
+	case Pedal.PressRelease:
+		if pressed {
+			if action.OnPress != nil {
+				triggerAction(*action.OnPress)
+			}
+			pedalState[pedalID] = true
+			return
+		}
+
+		// Only fire after a matching press (eg. not if the pedal was held while the map changed)
+		if !pedalState[pedalID] {
+			Log.WriteToLogFile(fmt.Sprintf("Pedal %d release ignored: no matching press", pedalID))
+			return
+		}
+		pedalState[pedalID] = false
+		if action.OnRelease != nil {
+			triggerAction(*action.OnRelease)
+		}
+
+	case Pedal.StickyModifier:
+		if pressed {
+			pressSticky(pedalID, action)
+		}
+
+		// Release event does nothing in sticky modifier mode
+
+	case Pedal.LayerMomentary, Pedal.LayerToggle, Pedal.LayerOneshot:
+		switchLayer(pedalID, action, pressed)
 	}
 }
 
@@ -218,10 +310,13 @@ func ListenSerial(baudRate int, serialPort string) error {
 	// Clear input buffer on start
 	port.ResetInputBuffer()
 
+	// Set while reads keep failing (eg. the device was disconnected)
+	readFailing := false
+
 	for {
-		// Do not process events if disabled
+		// Do not process events if disabled, unless a disable pedal can enable StepKeys again
 		// 500ms sleep to avoid CPU spikes
-		if !readEnabled() {
+		if !readEnabled() && !hasDisablePedal() {
 			port.ResetInputBuffer()
 			time.Sleep(500 * time.Millisecond)
 			continue
@@ -229,11 +324,20 @@ func ListenSerial(baudRate int, serialPort string) error {
 
 		n, err := port.Read(buf)
 		if err != nil {
+			// Release events may never arrive, so release everything once
+			if !readFailing {
+				readFailing = true
+				Log.WriteToLogFile("Serial read failed, releasing held keys: " + err.Error())
+				resetPedals()
+			}
+
 			// Ignore errors like timeout or corrupted data
 			port.ResetInputBuffer()
 			time.Sleep(5 * time.Millisecond)
 			continue
 		}
+		readFailing = false
+
 		if n == 0 {
 			port.ResetInputBuffer() // just to be sure
 			continue
@@ -243,6 +347,8 @@ func ListenSerial(baudRate int, serialPort string) error {
 		// This prevents processing of the pedal press that happens after disabling
 		if readEnabled() {
 			handlePedalByte(buf[0])
+		} else {
+			handleDisabledByte(buf[0])
 		}
 	}
 }
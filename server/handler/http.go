@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Client used by http pedals, timeouts are set per request
+var httpClient = &http.Client{}
+
+// Send the request of an http pedal in the background
+// event is "press" or "release", used by the body template
+func sendHTTP(request *Pedal.HTTPRequest, event string) {
+	go func() {
+		if err := doHTTP(httpClient, request, event); err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("HTTP %s %s failed: %v", request.HTTPMethod(), request.URL, err))
+		}
+	}()
+}
+
+// Send the request and log the response status
+// Responses other than 2xx are returned as errors
+func doHTTP(client *http.Client, request *Pedal.HTTPRequest, event string) error {
+	body, err := request.RenderBody(event, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), request.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, request.HTTPMethod(), request.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	Log.WriteToLogFile(fmt.Sprintf("HTTP %s %s: %s in %s", request.HTTPMethod(), request.URL,
+		resp.Status, time.Since(start).Round(time.Millisecond)))
+	return nil
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// A request received by the test server
+type receivedRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+// Helper: start a server that records the requests and answers with status
+func newRecordingServer(t *testing.T, status int) (*httptest.Server, <-chan receivedRequest) {
+	t.Helper()
+
+	received := make(chan receivedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{method: r.Method, header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, received
+}
+
+func TestDoHTTPMethodHeadersBody(t *testing.T) {
+	server, received := newRecordingServer(t, http.StatusOK)
+
+	request := &Pedal.HTTPRequest{
+		Method:  http.MethodPut,
+		URL:     server.URL + "/webhook",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Body:    `{"event": "{{.Event}}", "time": "{{.Time}}"}`,
+	}
+	if err := doHTTP(server.Client(), request, "release"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-received
+	if got.method != http.MethodPut {
+		t.Errorf("method: got %s, want PUT", got.method)
+	}
+	if auth := got.header.Get("Authorization"); auth != "Bearer token" {
+		t.Errorf("Authorization header: got %q", auth)
+	}
+	if contentType := got.header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type header: got %q", contentType)
+	}
+
+	var body struct {
+		Event string `json:"event"`
+		Time  string `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(got.body), &body); err != nil {
+		t.Fatalf("body %q: %v", got.body, err)
+	}
+	if body.Event != "release" {
+		t.Errorf("event: got %q, want release", body.Event)
+	}
+	if _, err := time.Parse(time.RFC3339, body.Time); err != nil {
+		t.Errorf("time: %v", err)
+	}
+}
+
+func TestDoHTTPDefaults(t *testing.T) {
+	server, received := newRecordingServer(t, http.StatusNoContent)
+
+	if err := doHTTP(server.Client(), &Pedal.HTTPRequest{URL: server.URL}, "press"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-received
+	if got.method != http.MethodPost {
+		t.Errorf("method: got %s, want POST", got.method)
+	}
+	if got.body != "" || got.header.Get("Content-Type") != "" {
+		t.Errorf("empty template sent body %q with Content-Type %q", got.body, got.header.Get("Content-Type"))
+	}
+}
+
+func TestDoHTTPTemplateError(t *testing.T) {
+	server, received := newRecordingServer(t, http.StatusOK)
+
+	// Unknown fields fail, templates that do not render JSON fail too
+	for _, body := range []string{`{"x": "{{.Missing}}"}`, `event={{.Event}}`} {
+		if err := doHTTP(server.Client(), &Pedal.HTTPRequest{URL: server.URL, Body: body}, "press"); err == nil {
+			t.Errorf("body %q: no error", body)
+		}
+	}
+
+	select {
+	case <-received:
+		t.Error("request sent with a broken body")
+	default:
+	}
+}
+
+func TestDoHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	err := doHTTP(server.Client(), &Pedal.HTTPRequest{URL: server.URL, TimeoutMs: 50}, "press")
+	if err == nil {
+		t.Fatal("no timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %s despite the 50ms timeout", elapsed)
+	}
+}
+
+func TestDoHTTPStatusError(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		server, _ := newRecordingServer(t, status)
+
+		err := doHTTP(server.Client(), &Pedal.HTTPRequest{URL: server.URL}, "press")
+		if err == nil || !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("status %d: got error %v", status, err)
+		}
+	}
+}
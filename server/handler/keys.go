@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"slices"
+)
+
+// Currently held keyboard keys: key -> set of pedal IDs holding it
+// A key is released when its last owner lets go, so overlapping pedals do not release each other's keys
+// Guarded by stateMu
+var keyOwners = make(map[string]map[int]bool)
+
+// Checks if any pedal holds the key
+func isKeyHeld(key string) bool {
+	return len(keyOwners[key]) > 0
+}
+
+// Helper: convert []string to []interface{} (array of any) for robotgo
+func stringToAny(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+// Press and release a key
+// A held key only gets an extra key down, tapping it would release it for its owners
+func tapKey(key string) {
+	if isKeyHeld(key) {
+		input.KeyDown(key)
+		return
+	}
+	input.KeyTap(key, nil)
+}
+
+// Press and release the keys
+func tapKeys(keys []string) {
+	for _, key := range keys {
+		tapKey(key)
+	}
+}
+
+// Press and release the keys together, the last key is the main key
+func tapCombo(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	mainKey := keys[len(keys)-1]
+
+	// Held modifiers are already down, robotgo would release them after the tap
+	var mods []string
+	for _, mod := range keys[:len(keys)-1] {
+		if !isKeyHeld(mod) {
+			mods = append(mods, mod)
+		}
+	}
+
+	if !isKeyHeld(mainKey) {
+		input.KeyTap(mainKey, mods) // okay even if mods is empty
+		return
+	}
+
+	// Held main key: press the missing modifiers around an extra key down
+	for _, mod := range mods {
+		input.KeyDown(mod)
+	}
+	input.KeyDown(mainKey)
+	for _, mod := range slices.Backward(mods) {
+		input.KeyUp(mod)
+	}
+}
+
+// Press the keys down on behalf of a pedal and do not release them
+func pressKeys(pedalID int, keys []string) {
+	for _, key := range keys {
+		owners, ok := keyOwners[key]
+		if !ok {
+			owners = make(map[int]bool)
+			keyOwners[key] = owners
+		}
+
+		if len(owners) == 0 {
+			input.KeyDown(key)
+		}
+		owners[pedalID] = true
+	}
+}
+
+// Release the keys held by a pedal
+// Keys that are still held by other pedals stay down
+func releaseKeys(pedalID int, keys []string) {
+	for _, key := range keys {
+		owners := keyOwners[key]
+		if !owners[pedalID] {
+			continue
+		}
+
+		delete(owners, pedalID)
+		if len(owners) == 0 {
+			input.KeyUp(key)
+			delete(keyOwners, key)
+		}
+	}
+}
+
+// Release every held key regardless of its owners
+// Called from resetPedals
+func releaseAllKeys() {
+	for key := range keyOwners {
+		input.KeyUp(key)
+	}
+	clear(keyOwners)
+}
+
+// Returns the held keys and the pedals holding them: key -> sorted pedal IDs
+// Used by the API
+func GetHeldKeys() map[string][]int {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	held := make(map[string][]int, len(keyOwners))
+	for key, owners := range keyOwners {
+		ids := make([]int, 0, len(owners))
+		for pedalID := range owners {
+			ids = append(ids, pedalID)
+		}
+		slices.Sort(ids)
+		held[key] = ids
+	}
+
+	return held
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// A layer activated by a held layerMomentary pedal
+type momentaryLayer struct {
+	pedalID int
+	layer   string
+}
+
+// Layer state, all fields are guarded by stateMu
+// The base layer is represented by an empty string
+var (
+	toggledLayer    string           // latched by a layerToggle pedal
+	oneshotLayer    string           // applied to the next pedal press only
+	momentaryLayers []momentaryLayer // held layerMomentary pedals, the last one wins
+
+	// The action a pedal was engaged with on press: pedalID -> action
+	// Releases and latched toggles use this, so a layer change does not leave keys stuck
+	engagedActions = make(map[int]Pedal.PedalAction)
+)
+
+// Returns the name of the active layer
+// Precedence: held momentary layers, then the oneshot layer, then the toggled layer
+func activeLayer() string {
+	if n := len(momentaryLayers); n > 0 {
+		return momentaryLayers[n-1].layer
+	}
+	if oneshotLayer != "" {
+		return oneshotLayer
+	}
+	return toggledLayer
+}
+
+// Look up the action of a pedal on the active layer
+// Falls through to the base action if the pedal is not defined on the active layer
+func resolveAction(action Pedal.PedalAction) Pedal.PedalAction {
+	if layer := activeLayer(); layer != "" {
+		if layerAction, ok := action.Layers[layer]; ok {
+			return layerAction
+		}
+	}
+	return action
+}
+
+// Clear the oneshot layer once a regular pedal was pressed on it
+func consumeOneshotLayer(action Pedal.PedalAction) {
+	if oneshotLayer == "" || Pedal.IsLayerSwitch(action.Behaviour) {
+		return
+	}
+
+	oneshotLayer = ""
+	logActiveLayer()
+}
+
+// Handle the press and release events of layer switch pedals
+func switchLayer(pedalID int, action Pedal.PedalAction, pressed bool) {
+	switch action.Behaviour {
+	case Pedal.LayerMomentary:
+		if pressed {
+			momentaryLayers = append(momentaryLayers, momentaryLayer{pedalID: pedalID, layer: action.Layer})
+		} else {
+			momentaryLayers = slices.DeleteFunc(momentaryLayers, func(m momentaryLayer) bool {
+				return m.pedalID == pedalID
+			})
+		}
+
+	case Pedal.LayerToggle:
+		if !pressed {
+			return
+		}
+		if toggledLayer == action.Layer {
+			toggledLayer = ""
+		} else {
+			toggledLayer = action.Layer
+		}
+
+	case Pedal.LayerOneshot:
+		if !pressed {
+			return
+		}
+		// Pressing the pedal again cancels the pending oneshot layer
+		if oneshotLayer == action.Layer {
+			oneshotLayer = ""
+		} else {
+			oneshotLayer = action.Layer
+		}
+	}
+
+	logActiveLayer()
+}
+
+// Helper: log the active layer after a change
+func logActiveLayer() {
+	layer := activeLayer()
+	if layer == "" {
+		layer = "base"
+	}
+	Log.WriteToLogFile(fmt.Sprintf("Active layer: %s", layer))
+}
+
+// Return to the base layer and forget engaged actions
+// Called from resetPedals
+func resetLayers() {
+	toggledLayer = ""
+	oneshotLayer = ""
+	momentaryLayers = nil
+	clear(engagedActions)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	Log "stepkeys/server/logging"
+	MPRIS "stepkeys/server/mpris"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Media player calls go through D-Bus and may block for a while
+// They run one after another in the background, so a quick press and release keeps its order
+var (
+	mediaQueue     = make(chan func(), 16)
+	mediaQueueOnce sync.Once
+)
+
+// Helper: queue a media player call, dropped (logged) if the queue is full
+func queueMedia(name string, call func() error) {
+	mediaQueueOnce.Do(func() {
+		go func() {
+			for call := range mediaQueue {
+				call()
+			}
+		}()
+	})
+
+	select {
+	case mediaQueue <- func() {
+		if err := call(); err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("Media %s failed: %v", name, err))
+		}
+	}:
+	default:
+		Log.WriteToLogFile(fmt.Sprintf("Media %s dropped: too many pending calls.", name))
+	}
+}
+
+// Run the operation of a oneshot media pedal
+func controlMedia(media *Pedal.MediaAction) {
+	player := media.Player
+
+	switch media.Operation {
+	case Pedal.MediaPlayPause:
+		queueMedia(media.Operation, func() error { return MPRIS.PlayPause(player, media.Rewind()) })
+	case Pedal.MediaPlay:
+		queueMedia(media.Operation, func() error { return MPRIS.Play(player, media.Rewind()) })
+	case Pedal.MediaPause:
+		queueMedia(media.Operation, func() error { return MPRIS.Pause(player) })
+	case Pedal.MediaSeek:
+		queueMedia(media.Operation, func() error { return MPRIS.Seek(player, media.Offset()) })
+	case Pedal.MediaRate:
+		queueMedia(media.Operation, func() error { return MPRIS.SetRate(player, media.Rate) })
+	case Pedal.MediaNext:
+		queueMedia(media.Operation, func() error { return MPRIS.Next(player) })
+	case Pedal.MediaPrevious:
+		queueMedia(media.Operation, func() error { return MPRIS.Previous(player) })
+	}
+}
+
+// Hold-to-play: play while the pedal is held or latched, rewinding first
+func pressMedia(media *Pedal.MediaAction) {
+	queueMedia("play", func() error { return MPRIS.Play(media.Player, media.Rewind()) })
+}
+
+// Hold-to-play: pause when the pedal is released or unlatched
+func releaseMedia(media *Pedal.MediaAction) {
+	queueMedia("pause", func() error { return MPRIS.Pause(media.Player) })
+}
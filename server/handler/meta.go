@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Functions meta pedals call
+// Set by the config package, which imports this package
+type MetaCallbacks struct {
+	ToggleEnabled func()
+	NextProfile   func()
+	ReloadConfig  func()
+	PauseFor      func(d time.Duration)
+}
+
+var metaCallbacks MetaCallbacks
+
+// Register the functions meta pedals call
+func SetMetaCallbacks(c MetaCallbacks) {
+	metaCallbacks = c
+}
+
+// Run a meta action
+// The callbacks reset the pedals (locking stateMu), so they run once the pedal event was handled
+func triggerMeta(action Pedal.PedalAction) {
+	switch action.Mode {
+	case Pedal.Disable:
+		go metaCallbacks.ToggleEnabled()
+	case Pedal.ReleaseAll:
+		Log.WriteToLogFile("Releasing all keys and buttons.")
+		go resetPedals()
+	case Pedal.NextProfile:
+		go metaCallbacks.NextProfile()
+	case Pedal.ReloadConfig:
+		go metaCallbacks.ReloadConfig()
+	case Pedal.PauseFor:
+		d, _ := time.ParseDuration(action.Duration) // checked by validation
+		go metaCallbacks.PauseFor(d)
+	}
+}
+
+// Checks if the pedal map has a disable pedal that can enable StepKeys again
+func hasDisablePedal() bool {
+	for _, action := range readPedalMap() {
+		if action.Mode == Pedal.Disable {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle a raw pedal byte while StepKeys is disabled
+// Only the press of a disable pedal (on the base layer) is handled, it enables StepKeys
+func handleDisabledByte(b byte) {
+	pedalID := int(b & 0x7F)
+	pressed := (b & 0x80) != 0
+
+	action, ok := readPedalMap()[fmt.Sprintf("%d", pedalID)]
+	if !ok || !pressed || action.Mode != Pedal.Disable {
+		return
+	}
+
+	Log.WriteToLogFile(fmt.Sprintf("Pedal %d pressed while disabled, enabling StepKeys.", pedalID))
+	metaCallbacks.ToggleEnabled()
+}
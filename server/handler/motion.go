@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"time"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// Time between two pointer movements of the motion loop
+const motionTick = 10 * time.Millisecond
+
+// A held mouseMove pedal
+type mover struct {
+	dirX, dirY float64 // unit direction, screen coordinates (y grows downwards)
+	motion     Pedal.MouseMotion
+	start      time.Time
+}
+
+// Mouse pointer movement state, guarded by stateMu
+// The motion loop only runs while at least one mouseMove pedal is held
+var (
+	movers     = make(map[int]*mover) // pedalID -> mover
+	motionStop chan struct{}          // closes the running motion loop, nil if no loop runs
+	remX, remY float64                // sub-pixel movement carried over between ticks
+)
+
+// Helper: convert a direction name to a unit vector
+func directionVector(direction string) (float64, float64) {
+	switch direction {
+	case "up":
+		return 0, -1
+	case "down":
+		return 0, 1
+	case "left":
+		return -1, 0
+	case "right":
+		return 1, 0
+	}
+	return 0, 0
+}
+
+// Start moving the pointer on behalf of a pedal
+// Movers add up, so two held direction pedals move the pointer diagonally
+func startMove(pedalID int, action Pedal.PedalAction) {
+	m := &mover{start: time.Now()}
+	m.dirX, m.dirY = directionVector(action.Direction)
+	if action.Motion != nil {
+		m.motion = *action.Motion
+	}
+	movers[pedalID] = m
+
+	if motionStop == nil {
+		motionStop = make(chan struct{})
+		go motionLoop(motionStop)
+	}
+}
+
+// Stop moving the pointer on behalf of a pedal
+func stopMove(pedalID int) {
+	delete(movers, pedalID)
+	if len(movers) == 0 {
+		stopMotionLoop()
+	}
+}
+
+// Stop the motion loop, if it runs
+func stopMotionLoop() {
+	if motionStop != nil {
+		close(motionStop)
+		motionStop = nil
+	}
+	remX, remY = 0, 0
+}
+
+// Move the pointer every tick until stopped
+func motionLoop(stop chan struct{}) {
+	ticker := time.NewTicker(motionTick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if !moveOnce(stop, now, now.Sub(last)) {
+				return
+			}
+			last = now
+		}
+	}
+}
+
+// Move the pointer by the combined velocity of the held movers
+// The stop check happens under stateMu, so the pointer never moves after a release
+func moveOnce(stop chan struct{}, now time.Time, elapsed time.Duration) bool {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+
+	var velX, velY float64
+	for _, m := range movers {
+		speed := m.motion.SpeedAfter(now.Sub(m.start))
+		velX += m.dirX * speed
+		velY += m.dirY * speed
+	}
+
+	remX += velX * elapsed.Seconds()
+	remY += velY * elapsed.Seconds()
+
+	// Only move by whole pixels, keep the rest for the next tick
+	dx, dy := int(remX), int(remY)
+	remX -= float64(dx)
+	remY -= float64(dy)
+
+	if dx != 0 || dy != 0 {
+		input.MoveRelative(dx, dy)
+	}
+	return true
+}
+
+// Stop all pointer movement
+// Called from resetPedals
+func resetMotion() {
+	clear(movers)
+	stopMotionLoop()
+}
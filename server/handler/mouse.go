@@ -0,0 +1,57 @@
+package handler
+
+// Currently held mouse buttons: button -> set of pedal IDs holding it
+// Works like keyOwners, a button is released when its last owner lets go
+// Guarded by stateMu
+var buttonOwners = make(map[string]map[int]bool)
+
+// Click or double click a mouse button
+// A held button is not clicked, as the click would release it for its owners
+func clickButton(button string, double bool) {
+	if len(buttonOwners[button]) > 0 {
+		return
+	}
+	input.Click(button, double)
+}
+
+// Scroll the mouse wheel by the given number of steps (at least one)
+func scroll(direction string, steps int) {
+	input.Scroll(direction, max(steps, 1))
+}
+
+// Press a mouse button down on behalf of a pedal (eg. for dragging)
+func pressButton(pedalID int, button string) {
+	owners, ok := buttonOwners[button]
+	if !ok {
+		owners = make(map[int]bool)
+		buttonOwners[button] = owners
+	}
+
+	if len(owners) == 0 {
+		input.ButtonDown(button)
+	}
+	owners[pedalID] = true
+}
+
+// Release a mouse button held by a pedal
+func releaseButton(pedalID int, button string) {
+	owners := buttonOwners[button]
+	if !owners[pedalID] {
+		return
+	}
+
+	delete(owners, pedalID)
+	if len(owners) == 0 {
+		input.ButtonUp(button)
+		delete(buttonOwners, button)
+	}
+}
+
+// Release every held mouse button regardless of its owners
+// Called from resetPedals
+func releaseAllButtons() {
+	for button := range buttonOwners {
+		input.ButtonUp(button)
+	}
+	clear(buttonOwners)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"fmt"
+
+	Log "stepkeys/server/logging"
+	OBSClient "stepkeys/server/obs"
+	Pedal "stepkeys/server/pedal"
+)
+
+// OBS operations without parameters -> obs-websocket request type
+var obsRequests = map[string]string{
+	Pedal.OBSStartRecord:  "StartRecord",
+	Pedal.OBSStopRecord:   "StopRecord",
+	Pedal.OBSToggleRecord: "ToggleRecord",
+	Pedal.OBSStartStream:  "StartStream",
+	Pedal.OBSStopStream:   "StopStream",
+	Pedal.OBSToggleStream: "ToggleStream",
+}
+
+// Run the operation of an obs pedal in the background, it waits for OBS to respond
+func controlOBS(obs *Pedal.OBSAction) {
+	go func() {
+		var err error
+		switch obs.Operation {
+		case Pedal.OBSScene:
+			err = OBSClient.SwitchScene(obs.Scene)
+		case Pedal.OBSToggleSource:
+			err = OBSClient.ToggleSource(obs.Scene, obs.Source)
+		case Pedal.OBSToggleMute:
+			err = OBSClient.ToggleMute(obs.Input)
+		case Pedal.OBSHotkey:
+			err = OBSClient.TriggerHotkey(obs.Hotkey)
+		default:
+			_, err = OBSClient.Request(obsRequests[obs.Operation], nil)
+		}
+
+		if err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("OBS %s failed: %v", obs.Operation, err))
+		}
+	}()
+}
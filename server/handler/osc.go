@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+
+	Log "stepkeys/server/logging"
+	OSCNet "stepkeys/server/osc"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Send the press or release message of an osc pedal in the background
+func sendOSC(osc *Pedal.OSCAction, pressed bool) {
+	target := osc.Target
+	build := osc.PressMessage
+	if !pressed {
+		build = osc.ReleaseMessage
+	}
+
+	go func() {
+		address, args, err := build() // checked by validation
+		if err == nil {
+			err = OSCNet.Send(target, OSCNet.Message{Address: address, Args: args})
+		}
+		if err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("Failed to send OSC message to %s: %v", target, err))
+		}
+	}()
+}
+
+// Handle a pedal event that did not come from the serial port (eg. an OSC message)
+// Works like a real pedal with the same ID, including the handling while disabled
+func VirtualPedalEvent(pedalID int, pressed bool) error {
+	if pedalID < 0 || pedalID > 0x7F {
+		return fmt.Errorf("invalid pedal ID %d (use 0-127)", pedalID)
+	}
+
+	b := byte(pedalID)
+	if pressed {
+		b |= 0x80
+	}
+
+	if readEnabled() {
+		handlePedalByte(b)
+	} else {
+		handleDisabledByte(b)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Snippet "stepkeys/server/snippet"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// Clipboard contents saved by a paste pedal, restored once the restore timer fires
+// Pastes in quick succession keep the contents saved by the first one
+// Guarded by its own mutex, the timer runs outside of stateMu
+var (
+	pasteSaved   string
+	pastedText   string // the text written by the last paste
+	pastePending bool   // the saved contents were not restored yet
+	pasteGen     int    // invalidates the restore timers of earlier pastes
+	pasteMu      sync.Mutex
+)
+
+// Render a text snippet and paste it through the clipboard
+// Falls back to typing the text if the clipboard cannot be used
+func pasteText(text string, restoreAfter time.Duration) {
+	rendered, err := Snippet.Render(text)
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to render paste snippet: %v", err))
+		return
+	}
+
+	pasteMu.Lock()
+	defer pasteMu.Unlock()
+
+	// Save the clipboard, unless a previous paste is still waiting to restore it
+	if !pastePending {
+		saved, err := robotgo.ReadAll()
+		if err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("Failed to read the clipboard, typing instead: %v", err))
+			input.TypeStr(rendered)
+			return
+		}
+		pasteSaved = saved
+	}
+
+	if err := robotgo.WriteAll(rendered); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to write the clipboard, typing instead: %v", err))
+		input.TypeStr(rendered)
+		return
+	}
+	pastedText = rendered
+	pastePending = true
+
+	tapCombo(pasteCombo())
+
+	pasteGen++
+	gen := pasteGen
+	time.AfterFunc(restoreAfter, func() { restoreClipboard(gen) })
+}
+
+// Helper: the paste shortcut of the platform
+func pasteCombo() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{"cmd", "v"}
+	}
+	return []string{"ctrl", "v"}
+}
+
+// Restore the clipboard saved by the last paste
+// Skipped if something else was copied in the meantime
+func restoreClipboard(gen int) {
+	pasteMu.Lock()
+	defer pasteMu.Unlock()
+
+	// A later paste restores the clipboard instead
+	if gen != pasteGen {
+		return
+	}
+	pastePending = false
+
+	current, err := robotgo.ReadAll()
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to read the clipboard, not restoring it: %v", err))
+		return
+	}
+	if current != pastedText {
+		return
+	}
+
+	if err := robotgo.WriteAll(pasteSaved); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to restore the clipboard: %v", err))
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// Tap the keys together on behalf of a plugin, the last key is the main key
+func TapKeys(keys []string) error {
+	if !readEnabled() {
+		return errors.New("StepKeys is disabled")
+	}
+	for _, key := range keys {
+		if _, ok := Pedal.ValidKeys[key]; !ok {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	tapCombo(keys)
+	return nil
+}
+
+// Type text on behalf of a plugin
+func TypeText(text string) error {
+	if !readEnabled() {
+		return errors.New("StepKeys is disabled")
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	input.TypeStr(text)
+	return nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// Action level rate limiting, guarded by stateMu
+// This is separate from switch debounce, it only applies to accepted pedal presses that trigger an action
+var (
+	lastActions   = make(map[int]time.Time) // pedalID -> time of the last accepted action, used by cooldowns
+	recentActions []time.Time               // accepted actions within the last second, used by the global limit
+	maxPerSecond  int                       // 0 means unlimited
+)
+
+// Sync the global max actions per second limit with the app config
+func UpdateRateLimit(limit int) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	maxPerSecond = max(limit, 0)
+}
+
+// Check the cooldown of the pedal and the global limit before an action is triggered
+// Returns the reason if the press has to be suppressed, otherwise the action is recorded
+func checkRateLimit(pedalID int, action Pedal.PedalAction) string {
+	now := time.Now()
+
+	if action.CooldownMs > 0 {
+		if last, ok := lastActions[pedalID]; ok {
+			left := time.Duration(action.CooldownMs)*time.Millisecond - now.Sub(last)
+			if left > 0 {
+				return fmt.Sprintf("cooldown (%dms left)", left.Milliseconds())
+			}
+		}
+	}
+
+	// Drop actions that left the one second window
+	for len(recentActions) > 0 && now.Sub(recentActions[0]) >= time.Second {
+		recentActions = recentActions[1:]
+	}
+
+	if maxPerSecond > 0 && len(recentActions) >= maxPerSecond {
+		return fmt.Sprintf("rate limit (max %d actions per second)", maxPerSecond)
+	}
+
+	lastActions[pedalID] = now
+	recentActions = append(recentActions, now)
+	return ""
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"time"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+// Running auto-repeat loops: pedalID -> stop channel
+// Guarded by stateMu
+var repeaters = make(map[int]chan struct{})
+
+// Start re-tapping the keys of a held pedal
+// The first tap is sent by the caller, the loop only handles the repeats
+func startRepeat(pedalID int, action Pedal.PedalAction) {
+	stopRepeat(pedalID)
+
+	stop := make(chan struct{})
+	repeaters[pedalID] = stop
+
+	delay := action.AutoRepeat.Delay()
+	interval := action.AutoRepeat.Interval()
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for repeatOnce(stop, action) {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Send one repeated tap unless the loop was stopped
+// The stop check happens under stateMu, so no tap can follow a stop
+func repeatOnce(stop chan struct{}, action Pedal.PedalAction) bool {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+
+	triggerAction(action)
+	return true
+}
+
+// Stop the auto-repeat loop of a pedal, if any
+func stopRepeat(pedalID int) {
+	if stop, ok := repeaters[pedalID]; ok {
+		close(stop)
+		delete(repeaters, pedalID)
+	}
+}
+
+// Stop all auto-repeat loops
+// Called from resetPedals
+func resetRepeats() {
+	for pedalID := range repeaters {
+		stopRepeat(pedalID)
+	}
+}
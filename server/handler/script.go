@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	OS "stepkeys/server/os"
+	Pedal "stepkeys/server/pedal"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Maximum number of response body bytes returned by sk.http
+const scriptHTTPBodyLimit = 64 * 1024
+
+// A running script
+type scriptRun struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	ownerID int // key owner ID of the run, negative so it never matches a pedal
+}
+
+// Running scripts: script action -> run
+// Keyed by the action pointer like commands, a script is skipped while it is still running
+// Guarded by its own mutex, lock it after stateMu if both are needed
+var (
+	scripts       = make(map[*Pedal.ScriptAction]*scriptRun)
+	scriptOwnerID = 0
+	scriptsMu     sync.Mutex
+)
+
+func init() {
+	Pedal.CompileScript = func(script Pedal.ScriptAction) error {
+		_, err := compileScript(script)
+		return err
+	}
+}
+
+// Directory of script files, inside the StepKeys directory
+const scriptsDirName = "scripts"
+
+// Returns the StepKeys directory, a var so tests can use a temp directory
+var exeDir = OS.GetExeDir
+
+// Load and compile a script
+// Used by validation and before every run, so edits to script files are picked up
+func compileScript(script Pedal.ScriptAction) (*lua.FunctionProto, error) {
+	name, source := "<inline>", script.Source
+	if source == "" {
+		// Script files cannot leave the scripts directory (also through symlinks),
+		// validation errors would show parts of other files
+		name = filepath.Join(scriptsDirName, script.File)
+		if !filepath.IsLocal(script.File) {
+			return nil, fmt.Errorf("script file %q is not inside the %s directory", script.File, scriptsDirName)
+		}
+
+		root, err := os.OpenRoot(filepath.Join(exeDir(), scriptsDirName))
+		if err != nil {
+			return nil, err
+		}
+		defer root.Close()
+
+		data, err := root.ReadFile(script.File)
+		if err != nil {
+			return nil, err
+		}
+		source = string(data)
+	}
+
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
+
+// Start a script in the background
+func runScript(script *Pedal.ScriptAction) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+
+	if _, running := scripts[script]; running {
+		Log.WriteToLogFile("Script skipped: still running.")
+		return
+	}
+
+	scriptOwnerID--
+	ctx, cancel := context.WithTimeout(context.Background(), script.Timeout())
+	run := &scriptRun{ctx: ctx, cancel: cancel, ownerID: scriptOwnerID}
+	scripts[script] = run
+
+	go func() {
+		run.execute(script)
+		cancel()
+
+		scriptsMu.Lock()
+		delete(scripts, script)
+		scriptsMu.Unlock()
+	}()
+}
+
+// Stop every running script
+// Called from resetPedals, held keys are released separately
+func resetScripts() {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+
+	for _, run := range scripts {
+		run.cancel()
+	}
+}
+
+// Compile and run the script, then release the keys it left pressed
+func (r *scriptRun) execute(script *Pedal.ScriptAction) {
+	proto, err := compileScript(*script)
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Script error: %v", err))
+		return
+	}
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+
+	openScriptLibs(L, script.Permissions)
+	L.SetGlobal("sk", r.api(L, script.Permissions))
+	L.SetContext(r.ctx)
+
+	L.Push(L.NewFunctionFromProto(proto))
+	err = L.PCall(0, lua.MultRet, nil)
+
+	switch {
+	case errors.Is(r.ctx.Err(), context.DeadlineExceeded):
+		Log.WriteToLogFile(fmt.Sprintf("Script stopped after %s.", script.Timeout()))
+	case errors.Is(r.ctx.Err(), context.Canceled):
+		Log.WriteToLogFile("Script stopped.")
+	case err != nil:
+		Log.WriteToLogFile(fmt.Sprintf("Script error: %v", err))
+	}
+
+	stateMu.Lock()
+	releaseOwnedKeys(r.ownerID)
+	stateMu.Unlock()
+}
+
+// A Lua standard library and its loader
+type scriptLib struct {
+	name string
+	open lua.LGFunction
+}
+
+// Open the standard libraries the script is allowed to use
+// Without the fs permission, nothing can touch files (or load modules), os.exit and os.execute are never available
+func openScriptLibs(L *lua.LState, permissions Pedal.ScriptPermissions) {
+	libs := []scriptLib{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	}
+	if permissions.FS {
+		libs = append(libs, scriptLib{lua.IoLibName, lua.OpenIo}, scriptLib{lua.OsLibName, lua.OpenOs})
+	}
+
+	for _, lib := range libs {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	if !permissions.FS {
+		for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+			L.SetGlobal(name, lua.LNil)
+		}
+	} else if osLib, ok := L.GetGlobal(lua.OsLibName).(*lua.LTable); ok {
+		osLib.RawSetString("exit", lua.LNil)
+		osLib.RawSetString("execute", lua.LNil)
+	}
+}
+
+// Build the sk table, the StepKeys API of scripts
+func (r *scriptRun) api(L *lua.LState, permissions Pedal.ScriptPermissions) *lua.LTable {
+	funcs := map[string]lua.LGFunction{
+		// sk.tap(key, ...): tap the keys together, the last key is the main key
+		"tap": func(L *lua.LState) int {
+			keys := checkScriptKeys(L)
+			r.lock(L)
+			defer stateMu.Unlock()
+			tapCombo(keys)
+			return 0
+		},
+		// sk.press(key, ...): hold the keys down until released or the script ends
+		"press": func(L *lua.LState) int {
+			keys := checkScriptKeys(L)
+			r.lock(L)
+			defer stateMu.Unlock()
+			pressKeys(r.ownerID, keys)
+			return 0
+		},
+		// sk.release(key, ...): release keys pressed by the script
+		"release": func(L *lua.LState) int {
+			keys := checkScriptKeys(L)
+			r.lock(L)
+			defer stateMu.Unlock()
+			releaseKeys(r.ownerID, keys)
+			return 0
+		},
+		// sk.type(text): type the text
+		"type": func(L *lua.LState) int {
+			text := L.CheckString(1)
+			r.lock(L)
+			defer stateMu.Unlock()
+			input.TypeStr(text)
+			return 0
+		},
+		// sk.sleep(ms): wait, counts towards the time limit
+		"sleep": func(L *lua.LState) int {
+			ms := L.CheckInt(1)
+			select {
+			case <-time.After(time.Duration(ms) * time.Millisecond):
+			case <-r.ctx.Done():
+				L.RaiseError("script stopped")
+			}
+			return 0
+		},
+		// sk.pedal(id): true if the toggle or hold pedal is latched or held
+		"pedal": func(L *lua.LState) int {
+			pedalID := L.CheckInt(1)
+			r.lock(L)
+			defer stateMu.Unlock()
+			L.Push(lua.LBool(pedalState[pedalID]))
+			return 1
+		},
+		// sk.get(name): read a persistent variable, nil if not set
+		"get": func(L *lua.LState) int {
+			L.Push(getScriptVar(L.CheckString(1)))
+			return 1
+		},
+		// sk.set(name, value): write a persistent variable (string, number, boolean or nil to delete)
+		"set": func(L *lua.LState) int {
+			name := L.CheckString(1)
+			value := L.Get(2)
+			switch value.Type() {
+			case lua.LTNil, lua.LTString, lua.LTNumber, lua.LTBool:
+				setScriptVar(name, value)
+			default:
+				L.ArgError(2, "string, number, boolean or nil expected")
+			}
+			return 0
+		},
+		// sk.log(message): write to the StepKeys log
+		"log": func(L *lua.LState) int {
+			Log.WriteToLogFile("Script: " + L.CheckString(1))
+			return 0
+		},
+	}
+
+	// sk.http(method, url, [body]): send a request, returns the status code and the response body
+	if permissions.Net {
+		funcs["http"] = func(L *lua.LState) int {
+			req, err := http.NewRequestWithContext(r.ctx, strings.ToUpper(L.CheckString(1)), L.CheckString(2),
+				strings.NewReader(L.OptString(3, "")))
+			if err != nil {
+				L.RaiseError("%v", err)
+			}
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				L.RaiseError("%v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, scriptHTTPBodyLimit))
+			L.Push(lua.LNumber(resp.StatusCode))
+			L.Push(lua.LString(body))
+			return 2
+		}
+	}
+
+	return L.SetFuncs(L.NewTable(), funcs)
+}
+
+// Helper: lock stateMu for an API call, unless the script was stopped
+// A stopped script must not press keys after resetPedals released them
+func (r *scriptRun) lock(L *lua.LState) {
+	stateMu.Lock()
+	if r.ctx.Err() != nil {
+		stateMu.Unlock()
+		L.RaiseError("script stopped")
+	}
+}
+
+// Helper: read the key name arguments of an API call
+func checkScriptKeys(L *lua.LState) []string {
+	keys := make([]string, L.GetTop())
+	for i := range keys {
+		keys[i] = L.CheckString(i + 1)
+		if _, ok := Pedal.ValidKeys[keys[i]]; !ok {
+			L.ArgError(i+1, fmt.Sprintf("invalid key %q", keys[i]))
+		}
+	}
+	return keys
+}
+
+// Release every key held by a key owner
+func releaseOwnedKeys(ownerID int) {
+	var keys []string
+	for key, owners := range keyOwners {
+		if owners[ownerID] {
+			keys = append(keys, key)
+		}
+	}
+	releaseKeys(ownerID, keys)
+}
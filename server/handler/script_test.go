@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Helper: use a temp directory as the StepKeys directory, with an empty scripts directory
+func useTempExeDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, scriptsDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := exeDir
+	exeDir = func() string { return dir }
+	t.Cleanup(func() { exeDir = saved })
+	return dir
+}
+
+// Helper: run the script until it ends and return the log lines it wrote
+func executeScript(t *testing.T, script *Pedal.ScriptAction) []string {
+	t.Helper()
+
+	mark := len(Log.ReadCurrentSessionLogs())
+	ctx, cancel := context.WithTimeout(context.Background(), script.Timeout())
+	defer cancel()
+
+	run := &scriptRun{ctx: ctx, cancel: cancel, ownerID: -1000}
+	run.execute(script)
+
+	return slices.Clone(Log.ReadCurrentSessionLogs()[mark:])
+}
+
+// Helper: check that a log line contains the text
+func expectLog(t *testing.T, lines []string, text string) {
+	t.Helper()
+	for _, line := range lines {
+		if strings.Contains(line, text) {
+			return
+		}
+	}
+	t.Errorf("no log line contains %q, got:\n%s", text, strings.Join(lines, "\n"))
+}
+
+func TestScriptPermissions(t *testing.T) {
+	source := `
+		for _, name in ipairs({"io", "os", "dofile", "loadfile", "require", "module", "string", "math", "table"}) do
+			sk.log(name .. ": " .. type(_G[name]))
+		end
+		sk.log("http: " .. type(sk.http))
+		if os then
+			sk.log("os.exit: " .. type(os.exit))
+			sk.log("os.execute: " .. type(os.execute))
+		end`
+
+	tests := []struct {
+		name        string
+		permissions Pedal.ScriptPermissions
+		want        []string
+	}{
+		{"none", Pedal.ScriptPermissions{}, []string{
+			"io: nil", "os: nil", "dofile: nil", "loadfile: nil", "require: nil", "module: nil",
+			"http: nil", "string: table", "math: table", "table: table",
+		}},
+		{"fs", Pedal.ScriptPermissions{FS: true}, []string{
+			"io: table", "os: table", "dofile: function", "loadfile: function",
+			"os.exit: nil", "os.execute: nil", "http: nil",
+		}},
+		{"net", Pedal.ScriptPermissions{Net: true}, []string{
+			"io: nil", "os: nil", "dofile: nil", "require: nil", "http: function",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := executeScript(t, &Pedal.ScriptAction{Source: source, Permissions: test.permissions})
+			for _, want := range test.want {
+				expectLog(t, lines, "Script: "+want)
+			}
+		})
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	start := time.Now()
+	lines := executeScript(t, &Pedal.ScriptAction{Source: "while true do end", TimeoutMs: 100})
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("script stopped after %s", elapsed)
+	}
+	expectLog(t, lines, "Script stopped after 100ms.")
+}
+
+func TestScriptVars(t *testing.T) {
+	dir := useTempExeDir(t)
+	scriptVarsLoaded = false
+	t.Cleanup(func() { scriptVarsLoaded = false })
+
+	executeScript(t, &Pedal.ScriptAction{Source: `
+		sk.set("takes", 41)
+		sk.set("scene", "intro")
+		sk.set("live", true)
+		sk.set("old", "x")
+		sk.set("old", nil)`})
+
+	data, err := os.ReadFile(filepath.Join(dir, "variables.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "old") {
+		t.Errorf("deleted variable saved: %s", data)
+	}
+
+	// Read the variables back from the file
+	scriptVarsLoaded = false
+	lines := executeScript(t, &Pedal.ScriptAction{Source: `
+		sk.log(sk.get("takes") + 1 .. " " .. sk.get("scene") .. " " .. tostring(sk.get("live")) .. " " .. tostring(sk.get("old")))`})
+	expectLog(t, lines, "Script: 42 intro true nil")
+
+	lines = executeScript(t, &Pedal.ScriptAction{Source: `sk.set("list", {})`})
+	expectLog(t, lines, "string, number, boolean or nil expected")
+}
+
+func TestScriptFiles(t *testing.T) {
+	dir := useTempExeDir(t)
+	scripts := filepath.Join(dir, scriptsDirName)
+
+	os.WriteFile(filepath.Join(dir, "secret.lua"), []byte("password = 'hunter2'"), 0644)
+	os.WriteFile(filepath.Join(scripts, "save.lua"), []byte(`sk.log("saved")`), 0644)
+	os.Mkdir(filepath.Join(scripts, "obs"), 0755)
+	os.WriteFile(filepath.Join(scripts, "obs", "scene.lua"), []byte(`sk.log("scene")`), 0644)
+	if err := os.Symlink(filepath.Join(dir, "secret.lua"), filepath.Join(scripts, "link.lua")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"save.lua", "obs/scene.lua"} {
+		if _, err := compileScript(Pedal.ScriptAction{File: file}); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+	}
+	expectLog(t, executeScript(t, &Pedal.ScriptAction{File: "save.lua"}), "Script: saved")
+
+	// Files outside the scripts directory are never read
+	outside := []string{"../secret.lua", filepath.Join(dir, "secret.lua"), "obs/../../secret.lua", "link.lua", "/etc/passwd"}
+	for _, file := range outside {
+		_, err := compileScript(Pedal.ScriptAction{File: file})
+		if err == nil {
+			t.Errorf("%s: compiled", file)
+		} else if strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("%s: error shows the file: %v", file, err)
+		}
+	}
+}
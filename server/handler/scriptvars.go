@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	Log "stepkeys/server/logging"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Persistent script variables: name -> string, float64 or bool
+// Shared by all scripts, loaded on first use and saved after every change
+var (
+	scriptVars       map[string]any
+	scriptVarsMu     sync.Mutex
+	scriptVarsLoaded bool
+)
+
+// Helper: path of the variable file next to the executable
+func scriptVarsFilePath() string {
+	return filepath.Join(exeDir(), "variables.json")
+}
+
+// Read a variable as a Lua value
+func getScriptVar(name string) lua.LValue {
+	scriptVarsMu.Lock()
+	defer scriptVarsMu.Unlock()
+
+	loadScriptVars()
+	switch value := scriptVars[name].(type) {
+	case string:
+		return lua.LString(value)
+	case float64:
+		return lua.LNumber(value)
+	case bool:
+		return lua.LBool(value)
+	default:
+		return lua.LNil
+	}
+}
+
+// Write a variable from a Lua value, nil deletes it
+func setScriptVar(name string, value lua.LValue) {
+	scriptVarsMu.Lock()
+	defer scriptVarsMu.Unlock()
+
+	loadScriptVars()
+	switch value := value.(type) {
+	case lua.LString:
+		scriptVars[name] = string(value)
+	case lua.LNumber:
+		scriptVars[name] = float64(value)
+	case lua.LBool:
+		scriptVars[name] = bool(value)
+	default:
+		delete(scriptVars, name)
+	}
+	saveScriptVars()
+}
+
+// Helper: read the variable file once, a missing or broken file starts empty
+func loadScriptVars() {
+	if scriptVarsLoaded {
+		return
+	}
+	scriptVarsLoaded = true
+	scriptVars = make(map[string]any)
+
+	data, err := os.ReadFile(scriptVarsFilePath())
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &scriptVars); err != nil {
+		Log.WriteToLogFile("Error parsing script variables, starting empty: " + err.Error())
+		scriptVars = make(map[string]any)
+	}
+}
+
+// Helper: write the variables to file
+func saveScriptVars() {
+	data, _ := json.MarshalIndent(scriptVars, "", "  ")
+	if err := os.WriteFile(scriptVarsFilePath(), data, 0644); err != nil {
+		Log.WriteToLogFile("Failed to save script variables: " + err.Error())
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Leader sequence state
+// Only one sequence can be in progress at a time, all fields are guarded by stateMu
+var (
+	leaderPedal   = -1 // pedal ID of the waiting leader, -1 if no sequence is in progress
+	leaderAction  Pedal.PedalAction
+	leaderPresses []string // pedal IDs pressed since the leader, in order
+	leaderTimer   *time.Timer
+	leaderGen     int // invalidates timers that fired after the sequence was finished
+
+	// Pedals whose press was consumed by a sequence, their release is ignored
+	capturedPedals = make(map[int]bool)
+)
+
+// Start waiting for a sequence after a leader pedal was pressed
+// Pressing a leader while another sequence is in progress restarts it
+func startSequence(pedalID int, action Pedal.PedalAction) {
+	if leaderPedal != -1 {
+		discardSequence("restarted by leader pedal " + strconv.Itoa(pedalID))
+	}
+
+	leaderPedal = pedalID
+	leaderAction = action
+	leaderPresses = nil
+	armSequenceTimer()
+
+	Log.WriteToLogFile(fmt.Sprintf("Leader pedal %d is waiting for a sequence (timeout: %dms).", pedalID, sequenceTimeoutMs()))
+}
+
+// Returns the timeout of the active leader in milliseconds
+func sequenceTimeoutMs() int {
+	if leaderAction.TimeoutMs > 0 {
+		return leaderAction.TimeoutMs
+	}
+	return Pedal.DefaultSequenceTimeoutMs
+}
+
+// (Re)start the timeout of the active sequence
+func armSequenceTimer() {
+	if leaderTimer != nil {
+		leaderTimer.Stop()
+	}
+
+	leaderGen++
+	gen := leaderGen
+
+	leaderTimer = time.AfterFunc(time.Duration(sequenceTimeoutMs())*time.Millisecond, func() {
+		stateMu.Lock()
+		defer stateMu.Unlock()
+
+		// The sequence was completed, discarded or restarted in the meantime
+		if gen != leaderGen || leaderPedal == -1 {
+			return
+		}
+
+		// A complete sequence that is also the prefix of a longer one is triggered on timeout
+		if seq, _ := matchSequence(); seq != nil {
+			completeSequence(*seq)
+			return
+		}
+
+		discardSequence("timed out")
+	})
+}
+
+// Find the sequence that exactly matches the presses so far
+// Also reports whether a longer sequence could still match
+func matchSequence() (exact *Pedal.LeaderSequence, longer bool) {
+	for i, seq := range leaderAction.Sequences {
+		if len(seq.Pedals) < len(leaderPresses) || !slices.Equal(seq.Pedals[:len(leaderPresses)], leaderPresses) {
+			continue
+		}
+
+		if len(seq.Pedals) == len(leaderPresses) {
+			exact = &leaderAction.Sequences[i]
+		} else {
+			longer = true
+		}
+	}
+
+	return exact, longer
+}
+
+// Feed a pedal event into the active sequence
+// Returns true if the event was consumed and must not be handled as a regular pedal event
+func captureSequenceInput(pedalID int, pressed bool, isLeader bool) bool {
+	if !pressed {
+		if capturedPedals[pedalID] {
+			delete(capturedPedals, pedalID)
+			return true
+		}
+		return false
+	}
+
+	// Leader presses are handled by the leader behaviour (restart)
+	if leaderPedal == -1 || isLeader {
+		return false
+	}
+
+	capturedPedals[pedalID] = true
+	leaderPresses = append(leaderPresses, strconv.Itoa(pedalID))
+
+	exact, longer := matchSequence()
+	switch {
+	case exact != nil && !longer:
+		completeSequence(*exact)
+	case exact == nil && !longer:
+		discardSequence("no matching sequence for " + formatPresses())
+	default:
+		armSequenceTimer()
+		Log.WriteToLogFile(fmt.Sprintf("Leader pedal %d sequence so far: %s", leaderPedal, formatPresses()))
+	}
+
+	return true
+}
+
+// Trigger the action of a completed sequence and finish it
+func completeSequence(seq Pedal.LeaderSequence) {
+	Log.WriteToLogFile(fmt.Sprintf("Leader pedal %d sequence matched: %s", leaderPedal, formatPresses()))
+	endSequence()
+	withStickyModifiers(func() { triggerAction(seq.Action) })
+}
+
+// Drop the active sequence without triggering anything
+func discardSequence(reason string) {
+	Log.WriteToLogFile(fmt.Sprintf("Leader pedal %d sequence discarded: %s", leaderPedal, reason))
+	endSequence()
+}
+
+// Clear the active sequence state
+// Captured pedals are kept, so their pending release events are still ignored
+func endSequence() {
+	if leaderTimer != nil {
+		leaderTimer.Stop()
+		leaderTimer = nil
+	}
+
+	leaderGen++
+	leaderPedal = -1
+	leaderAction = Pedal.PedalAction{}
+	leaderPresses = nil
+}
+
+// Clear all sequence state
+// Called from resetPedals
+func resetSequence() {
+	endSequence()
+	clear(capturedPedals)
+}
+
+// Helper: format the presses of the active sequence for logging
+func formatPresses() string {
+	return strings.Join(leaderPresses, " > ")
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"sync"
+
+	Log "stepkeys/server/logging"
+)
+
+// Pedal state changes are recorded under stateMu and handed to the callback in the background,
+// so slow WebSocket clients never block pedal handling
+type stateChange struct {
+	event   string
+	pedalID int
+	value   any
+}
+
+var (
+	stateCallback  func(event string, pedalID int, value any)
+	stateQueue     = make(chan stateChange, 64)
+	stateQueueOnce sync.Once
+)
+
+// Register the function pedal state changes are sent to
+// Set by the config package, which serves the state WebSocket
+func SetStateCallback(callback func(event string, pedalID int, value any)) {
+	stateCallback = callback
+}
+
+// Called when the runtime state of a pedal changed, the value must not be modified afterwards
+// Dropped (logged) if the queue is full
+func broadcastState(event string, pedalID int, value any) {
+	if stateCallback == nil {
+		return
+	}
+
+	stateQueueOnce.Do(func() {
+		go func() {
+			for change := range stateQueue {
+				stateCallback(change.event, change.pedalID, change.value)
+			}
+		}()
+	})
+
+	select {
+	case stateQueue <- stateChange{event: event, pedalID: pedalID, value: value}:
+	default:
+		Log.WriteToLogFile("Pedal state update dropped: too many pending updates.")
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Sticky modifier states, also sent to WebSocket clients
+type stickyState string
+
+const (
+	stickyOff    stickyState = "off"
+	stickyArmed  stickyState = "armed"  // applied to the next pedal action, then disarmed
+	stickyLocked stickyState = "locked" // held down until the pedal is pressed again
+)
+
+// Runtime state of a sticky modifier pedal
+type stickyModifier struct {
+	state     stickyState
+	keys      []string
+	lastPress time.Time
+}
+
+// Sticky modifier pedals: pedalID -> state
+// Guarded by stateMu
+var stickies = make(map[int]*stickyModifier)
+
+// Handle the press of a sticky modifier pedal
+// off -> armed, armed -> locked (double press) or off, locked -> off
+func pressSticky(pedalID int, action Pedal.PedalAction) {
+	now := time.Now()
+
+	sticky, ok := stickies[pedalID]
+	if !ok {
+		sticky = &stickyModifier{state: stickyOff}
+		stickies[pedalID] = sticky
+	}
+
+	window := time.Duration(action.DoublePressMs) * time.Millisecond
+	if action.DoublePressMs == 0 {
+		window = Pedal.DefaultDoublePressMs * time.Millisecond
+	}
+
+	switch sticky.state {
+	case stickyOff:
+		sticky.keys = action.Keys
+		setStickyState(pedalID, sticky, stickyArmed)
+
+	case stickyArmed:
+		if now.Sub(sticky.lastPress) <= window {
+			// Locked modifiers are held down, so they also apply to keys typed on the keyboard
+			pressKeys(pedalID, sticky.keys)
+			setStickyState(pedalID, sticky, stickyLocked)
+		} else {
+			setStickyState(pedalID, sticky, stickyOff)
+		}
+
+	case stickyLocked:
+		releaseKeys(pedalID, sticky.keys)
+		setStickyState(pedalID, sticky, stickyOff)
+	}
+
+	sticky.lastPress = now
+}
+
+// Wrap the press of a pedal action with the armed sticky modifiers and disarm them afterwards
+// Locked modifiers are already held down and need no extra work
+//
+// Without an OS keyboard hook, pedal actions are the only consumers of armed modifiers
+// A hook could hold armed modifiers down as well and call disarmStickies once a non-modifier key was typed
+func withStickyModifiers(trigger func()) {
+	var armed []int
+	for pedalID, sticky := range stickies {
+		if sticky.state == stickyArmed {
+			pressKeys(pedalID, sticky.keys)
+			armed = append(armed, pedalID)
+		}
+	}
+
+	trigger()
+
+	if len(armed) > 0 {
+		disarmStickies(armed)
+	}
+}
+
+// Release and disarm the given armed sticky modifiers
+func disarmStickies(pedalIDs []int) {
+	for _, pedalID := range pedalIDs {
+		sticky := stickies[pedalID]
+		releaseKeys(pedalID, sticky.keys)
+		setStickyState(pedalID, sticky, stickyOff)
+	}
+}
+
+// Helper: change the state of a sticky modifier, log it and notify WebSocket clients
+func setStickyState(pedalID int, sticky *stickyModifier, state stickyState) {
+	sticky.state = state
+	Log.WriteToLogFile(fmt.Sprintf("Sticky modifier pedal %d: %s", pedalID, state))
+	broadcastState("sticky", pedalID, state)
+}
+
+// Turn off every sticky modifier
+// Called from resetPedals, held keys are released separately
+func resetStickies() {
+	for pedalID, sticky := range stickies {
+		if sticky.state != stickyOff {
+			setStickyState(pedalID, sticky, stickyOff)
+		}
+	}
+	clear(stickies)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+
+	"github.com/go-vgo/robotgo"
+	"github.com/pkg/browser"
+)
+
+// System actions run in the background, they may block for a while and never hold keys
+
+// Open a URL with the default handler of its scheme
+func openURL(url string) {
+	go func() {
+		if err := browser.OpenURL(url); err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("Failed to open URL %q: %v", url, err))
+		}
+	}()
+}
+
+// Start an application without waiting for it to exit
+func launchApp(app string, args []string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Accepts application names and bundle paths, arguments need --args
+		openArgs := []string{"-a", app}
+		if len(args) > 0 {
+			openArgs = append(openArgs, "--args")
+			openArgs = append(openArgs, args...)
+		}
+		cmd = exec.Command("open", openArgs...)
+	case "windows":
+		// The empty string is the title of the start window
+		cmd = exec.Command("cmd", append([]string{"/C", "start", "", app}, args...)...)
+	default:
+		cmd = exec.Command(app, args...)
+	}
+
+	if err := cmd.Start(); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to launch %q: %v", app, err))
+		return
+	}
+	Log.WriteToLogFile(fmt.Sprintf("Launched %q.", app))
+
+	// Reap the process once it exits
+	go cmd.Wait()
+}
+
+// Bring the first matching window to the front
+func focusWindow(window Pedal.WindowMatch) {
+	go func() {
+		pid, ok := findWindow(window)
+		if !ok {
+			Log.WriteToLogFile(fmt.Sprintf("No window found for process %q, title %q.", window.Process, window.Title))
+			return
+		}
+
+		if err := robotgo.ActivePid(pid); err != nil {
+			Log.WriteToLogFile(fmt.Sprintf("Failed to focus window of process %d: %v", pid, err))
+		}
+	}()
+}
+
+// Helper: find the process owning the first matching window
+func findWindow(window Pedal.WindowMatch) (int, bool) {
+	if window.Process != "" {
+		// Case-insensitive substring match on the process name
+		pids, err := robotgo.FindIds(window.Process)
+		if err == nil {
+			for _, pid := range pids {
+				// Background processes with the same name have no window
+				if robotgo.GetTitle(pid) != "" {
+					return pid, true
+				}
+			}
+		}
+	}
+
+	if window.Title != "" {
+		title := strings.ToLower(window.Title)
+
+		processes, err := robotgo.Process()
+		if err != nil {
+			return 0, false
+		}
+		for _, process := range processes {
+			if strings.Contains(strings.ToLower(robotgo.GetTitle(process.Pid)), title) {
+				return process.Pid, true
+			}
+		}
+	}
+
+	return 0, false
+}
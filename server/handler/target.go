@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+	X11 "stepkeys/server/x11"
+)
+
+// Send the keys of a sequence or combo action to its target window instead of the focused one
+// Synthetic key events are tried first, focusing the window is the fallback
+func sendToWindow(action Pedal.PedalAction) {
+	target := action.TargetWindow
+	combo := action.Mode == Pedal.Combo
+
+	window, err := X11.FindWindow(target.Class, target.Title)
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Target window (class %q, title %q) not available: %v", target.Class, target.Title, err))
+		return
+	}
+
+	if !target.Focus {
+		err := X11.SendKeys(window, action.Keys, combo)
+		if err == nil {
+			return
+		}
+		Log.WriteToLogFile(fmt.Sprintf("Failed to send keys to the target window, focusing it instead: %v", err))
+	}
+
+	err = X11.WithFocus(window, func() {
+		if combo {
+			tapCombo(action.Keys)
+		} else {
+			tapKeys(action.Keys)
+		}
+	})
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to focus the target window: %v", err))
+	}
+}
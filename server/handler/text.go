@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"fmt"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+	Snippet "stepkeys/server/snippet"
+
+	"github.com/go-vgo/robotgo"
+)
+
+func init() {
+	Snippet.ReadClipboard = robotgo.ReadAll
+	Pedal.ParseText = func(text string) error {
+		_, err := Snippet.Parse(text)
+		return err
+	}
+}
+
+// Render a text snippet and type it
+func typeText(text string) {
+	rendered, err := Snippet.Render(text)
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Failed to render text snippet: %v", err))
+		return
+	}
+
+	input.TypeStr(rendered)
+}
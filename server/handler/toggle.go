@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"fmt"
+
+	Log "stepkeys/server/logging"
+	Pedal "stepkeys/server/pedal"
+)
+
+// Change the latched state of a toggle pedal and notify WebSocket clients
+func setToggleState(pedalID int, latched bool) {
+	pedalState[pedalID] = latched
+	broadcastState("toggle", pedalID, latched)
+}
+
+// Unlatch every other toggle pedal of a radio group and release their keys
+// Called before a toggle pedal of the group is latched
+func unlatchGroup(pedalID int, group string) {
+	for otherID, other := range engagedActions {
+		if otherID == pedalID || !pedalState[otherID] || other.Behaviour != Pedal.Toggle || other.Group != group {
+			continue
+		}
+
+		releaseAction(otherID, other)
+		setToggleState(otherID, false)
+		delete(engagedActions, otherID)
+
+		Log.WriteToLogFile(fmt.Sprintf("Pedal %d unlatched by pedal %d (group: %s)", otherID, pedalID, group))
+	}
+}
+
+// Notify WebSocket clients about toggle pedals that are unlatched by a reset
+// Called from resetPedals before the engaged actions are cleared
+func resetToggles() {
+	for pedalID, action := range engagedActions {
+		if action.Behaviour == Pedal.Toggle && pedalState[pedalID] {
+			broadcastState("toggle", pedalID, false)
+		}
+	}
+}
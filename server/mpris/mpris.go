@@ -0,0 +1,11 @@
+package mpris
+
+import (
+	"errors"
+)
+
+// Returned on systems without MPRIS (D-Bus session bus)
+var ErrUnsupported = errors.New("MPRIS is only available on Linux")
+
+// Returned when no media player matches
+var ErrNoPlayer = errors.New("no matching media player")
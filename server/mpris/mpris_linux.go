@@ -0,0 +1,200 @@
+//go:build linux
+
+package mpris
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// MPRIS D-Bus names (https://specifications.freedesktop.org/mpris-spec/latest/)
+const (
+	busPrefix   = "org.mpris.MediaPlayer2."
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	propsIface  = "org.freedesktop.DBus.Properties"
+)
+
+// Time a player may take to answer a call
+const callTimeout = 2 * time.Second
+
+// Session bus connection, opened on first use and reopened if it was closed
+// DBUS_SESSION_BUS_ADDRESS selects the bus, as usual
+var (
+	conn   *dbus.Conn
+	connMu sync.Mutex
+)
+
+// Toggle playback of a player
+// When playback resumes, the player is rewound first (use 0 to resume where it stopped)
+func PlayPause(filter string, rewind time.Duration) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		if rewind > 0 {
+			playing, err := isPlaying(ctx, player)
+			if err != nil {
+				return err
+			}
+			if playing {
+				return player.CallWithContext(ctx, playerIface+".Pause", 0).Err
+			}
+			if err := seek(ctx, player, -rewind); err != nil {
+				return err
+			}
+			return player.CallWithContext(ctx, playerIface+".Play", 0).Err
+		}
+		return player.CallWithContext(ctx, playerIface+".PlayPause", 0).Err
+	})
+}
+
+// Start playback of a player
+// If it was not playing, it is rewound first (use 0 to resume where it stopped)
+func Play(filter string, rewind time.Duration) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		if rewind > 0 {
+			playing, err := isPlaying(ctx, player)
+			if err != nil {
+				return err
+			}
+			if !playing {
+				if err := seek(ctx, player, -rewind); err != nil {
+					return err
+				}
+			}
+		}
+		return player.CallWithContext(ctx, playerIface+".Play", 0).Err
+	})
+}
+
+// Pause playback of a player
+func Pause(filter string) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		return player.CallWithContext(ctx, playerIface+".Pause", 0).Err
+	})
+}
+
+// Seek forward (positive offset) or backward (negative offset)
+func Seek(filter string, offset time.Duration) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		return seek(ctx, player, offset)
+	})
+}
+
+// Set the playback rate of a player, 1 is the normal speed
+func SetRate(filter string, rate float64) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		return player.CallWithContext(ctx, propsIface+".Set", 0, playerIface, "Rate", dbus.MakeVariant(rate)).Err
+	})
+}
+
+// Skip to the next track
+func Next(filter string) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		return player.CallWithContext(ctx, playerIface+".Next", 0).Err
+	})
+}
+
+// Skip to the previous track
+func Previous(filter string) error {
+	return withPlayer(filter, func(ctx context.Context, player dbus.BusObject) error {
+		return player.CallWithContext(ctx, playerIface+".Previous", 0).Err
+	})
+}
+
+// Helper: seek relative to the current position, MPRIS uses microseconds
+func seek(ctx context.Context, player dbus.BusObject, offset time.Duration) error {
+	return player.CallWithContext(ctx, playerIface+".Seek", 0, offset.Microseconds()).Err
+}
+
+// Helper: check if a player is playing
+func isPlaying(ctx context.Context, player dbus.BusObject) (bool, error) {
+	status, err := getProperty(ctx, player, playerIface, "PlaybackStatus")
+	if err != nil {
+		return false, err
+	}
+	return status.Value() == "Playing", nil
+}
+
+// Helper: read a property of a player
+func getProperty(ctx context.Context, player dbus.BusObject, iface, name string) (dbus.Variant, error) {
+	var value dbus.Variant
+	err := player.CallWithContext(ctx, propsIface+".Get", 0, iface, name).Store(&value)
+	return value, err
+}
+
+// Helper: find the player and run the call on it, with a time limit
+func withPlayer(filter string, call func(ctx context.Context, player dbus.BusObject) error) error {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	if conn == nil || !conn.Connected() {
+		c, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return fmt.Errorf("cannot connect to the D-Bus session bus: %w", err)
+		}
+		conn = c
+	}
+
+	player, err := findPlayer(ctx, strings.ToLower(filter))
+	if err != nil {
+		return err
+	}
+	return call(ctx, player)
+}
+
+// Helper: find the player to control
+// The filter is a case-insensitive substring of the bus name (eg. vlc) or the player name (Identity)
+// If several players match, a playing one is preferred, then a paused one
+func findPlayer(ctx context.Context, filter string) (dbus.BusObject, error) {
+	var names []string
+	if err := conn.BusObject().CallWithContext(ctx, "org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+
+	var best dbus.BusObject
+	bestRank := -1
+	for _, name := range names {
+		if !strings.HasPrefix(name, busPrefix) {
+			continue
+		}
+		player := conn.Object(name, objectPath)
+
+		if filter != "" && !strings.Contains(strings.ToLower(strings.TrimPrefix(name, busPrefix)), filter) {
+			identity, err := getProperty(ctx, player, rootIface, "Identity")
+			if err != nil {
+				continue
+			}
+			if id, ok := identity.Value().(string); !ok || !strings.Contains(strings.ToLower(id), filter) {
+				continue
+			}
+		}
+
+		rank := 0
+		if status, err := getProperty(ctx, player, playerIface, "PlaybackStatus"); err == nil {
+			switch status.Value() {
+			case "Playing":
+				rank = 2
+			case "Paused":
+				rank = 1
+			}
+		}
+		if rank > bestRank {
+			best, bestRank = player, rank
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNoPlayer
+	}
+	return best, nil
+}
@@ -0,0 +1,257 @@
+//go:build linux
+
+package mpris
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// Helper: start a private session bus for the test
+// Skips the test if dbus-daemon is not installed
+func startSessionBus(t *testing.T) {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Skipf("dbus-daemon did not print its address: %v", err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+
+	// The package connection belongs to the bus of the test
+	t.Cleanup(func() {
+		connMu.Lock()
+		defer connMu.Unlock()
+		if conn != nil {
+			conn.Close()
+			conn = nil
+		}
+	})
+}
+
+// A stand-in media player implementing the MPRIS methods the package calls
+type standIn struct {
+	props *prop.Properties
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// Helper: register a stand-in player as org.mpris.MediaPlayer2.<name>
+func newStandIn(t *testing.T, name, identity, status string) *standIn {
+	t.Helper()
+
+	c, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	player := &standIn{}
+	// Seek is exported as SeekBy, vet expects Seek methods to implement io.Seeker
+	if err := c.ExportWithMap(player, map[string]string{"SeekBy": "Seek"}, objectPath, playerIface); err != nil {
+		t.Fatal(err)
+	}
+	player.props, err = prop.Export(c, objectPath, prop.Map{
+		rootIface: {
+			"Identity": {Value: identity},
+		},
+		playerIface: {
+			"PlaybackStatus": {Value: status},
+			"Rate":           {Value: 1.0, Writable: true, Callback: player.setRate},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := c.RequestName(busPrefix+name, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("cannot own %s: %v", busPrefix+name, err)
+	}
+	return player
+}
+
+func (p *standIn) record(call string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func (p *standIn) setStatus(status string) {
+	p.props.SetMust(playerIface, "PlaybackStatus", status)
+}
+
+func (p *standIn) PlayPause() *dbus.Error {
+	p.record("PlayPause")
+	if p.props.GetMust(playerIface, "PlaybackStatus") == "Playing" {
+		p.setStatus("Paused")
+	} else {
+		p.setStatus("Playing")
+	}
+	return nil
+}
+
+func (p *standIn) Play() *dbus.Error {
+	p.record("Play")
+	p.setStatus("Playing")
+	return nil
+}
+
+func (p *standIn) Pause() *dbus.Error {
+	p.record("Pause")
+	p.setStatus("Paused")
+	return nil
+}
+
+func (p *standIn) Next() *dbus.Error {
+	p.record("Next")
+	return nil
+}
+
+func (p *standIn) Previous() *dbus.Error {
+	p.record("Previous")
+	return nil
+}
+
+func (p *standIn) SeekBy(offset int64) *dbus.Error {
+	p.record("Seek " + time.Duration(offset*int64(time.Microsecond)).String())
+	return nil
+}
+
+func (p *standIn) setRate(change *prop.Change) *dbus.Error {
+	p.record(fmt.Sprintf("Rate %v", change.Value))
+	return nil
+}
+
+// Helper: the calls received so far, cleared
+func (p *standIn) takeCalls() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	calls := strings.Join(p.calls, ", ")
+	p.calls = nil
+	return calls
+}
+
+func TestControls(t *testing.T) {
+	startSessionBus(t)
+	player := newStandIn(t, "vlc", "VLC media player", "Stopped")
+
+	steps := []struct {
+		control func() error
+		want    string
+	}{
+		{func() error { return PlayPause("", 0) }, "PlayPause"},
+		{func() error { return Pause("") }, "Pause"},
+		{func() error { return Play("", 0) }, "Play"},
+		{func() error { return Next("vlc") }, "Next"},
+		{func() error { return Previous("vlc") }, "Previous"},
+		{func() error { return Seek("", 5*time.Second) }, "Seek 5s"},
+		{func() error { return Seek("", -10*time.Second) }, "Seek -10s"},
+		{func() error { return SetRate("", 1.5) }, "Rate 1.5"},
+	}
+	for _, step := range steps {
+		if err := step.control(); err != nil {
+			t.Fatalf("%s: %v", step.want, err)
+		}
+		if got := player.takeCalls(); got != step.want {
+			t.Errorf("got calls %q, want %q", got, step.want)
+		}
+	}
+}
+
+func TestRewind(t *testing.T) {
+	startSessionBus(t)
+	player := newStandIn(t, "mpv", "mpv Media Player", "Paused")
+
+	// Resuming rewinds first
+	if err := PlayPause("", 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := player.takeCalls(); got != "Seek -3s, Play" {
+		t.Errorf("resume: got calls %q", got)
+	}
+
+	// Pausing does not
+	if err := PlayPause("", 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := player.takeCalls(); got != "Pause" {
+		t.Errorf("pause: got calls %q", got)
+	}
+
+	// Play only rewinds a player that is not playing
+	if err := Play("", 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := Play("", 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := player.takeCalls(); got != "Seek -3s, Play, Play" {
+		t.Errorf("play: got calls %q", got)
+	}
+}
+
+func TestFindPlayer(t *testing.T) {
+	startSessionBus(t)
+
+	if err := Next(""); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("no players: got error %v, want ErrNoPlayer", err)
+	}
+
+	paused := newStandIn(t, "vlc", "VLC media player", "Paused")
+	playing := newStandIn(t, "spotify", "Spotify", "Playing")
+	stopped := newStandIn(t, "mpv.instance42", "mpv Media Player", "Stopped")
+
+	filters := []struct {
+		filter string
+		want   *standIn
+	}{
+		{"", playing},        // A playing player is preferred
+		{"VLC", paused},      // Bus names match case-insensitively
+		{"mpv", stopped},     // Bus names with instance suffixes
+		{"media", paused},    // Identities, the paused player is preferred over the stopped one
+		{"Spotify", playing}, // Bus name and identity
+	}
+	for _, f := range filters {
+		if err := Next(f.filter); err != nil {
+			t.Fatalf("filter %q: %v", f.filter, err)
+		}
+		for _, player := range []*standIn{paused, playing, stopped} {
+			got := player.takeCalls()
+			if (player == f.want) != (got == "Next") {
+				t.Errorf("filter %q: player got calls %q", f.filter, got)
+			}
+		}
+	}
+
+	if err := Next("rhythmbox"); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("unmatched filter: got error %v, want ErrNoPlayer", err)
+	}
+}
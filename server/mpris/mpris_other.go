@@ -0,0 +1,37 @@
+//go:build !linux
+
+package mpris
+
+import (
+	"time"
+)
+
+// MPRIS is only available on Linux, every call fails with ErrUnsupported
+
+func PlayPause(filter string, rewind time.Duration) error {
+	return ErrUnsupported
+}
+
+func Play(filter string, rewind time.Duration) error {
+	return ErrUnsupported
+}
+
+func Pause(filter string) error {
+	return ErrUnsupported
+}
+
+func Seek(filter string, offset time.Duration) error {
+	return ErrUnsupported
+}
+
+func SetRate(filter string, rate float64) error {
+	return ErrUnsupported
+}
+
+func Next(filter string) error {
+	return ErrUnsupported
+}
+
+func Previous(filter string) error {
+	return ErrUnsupported
+}
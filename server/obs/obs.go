@@ -0,0 +1,362 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	Log "stepkeys/server/logging"
+)
+
+// Defaults of the connection config
+const (
+	DefaultURL         = "ws://localhost:4455"
+	DefaultReconnectMs = 5000
+)
+
+// Time OBS may take to accept the connection or answer a request
+const requestTimeout = 5 * time.Second
+
+// obs-websocket v5 message op codes
+const (
+	opHello           = 0
+	opIdentify        = 1
+	opIdentified      = 2
+	opRequest         = 6
+	opRequestResponse = 7
+)
+
+// Returned by Request while there is no connection to OBS
+var ErrNotConnected = errors.New("not connected to OBS")
+
+// Config describes the obs-websocket connection in config.json
+type Config struct {
+	// URL of the obs-websocket server, defaults to DefaultURL
+	URL string `json:"url,omitempty"`
+
+	// Password set in OBS (Tools -> WebSocket Server Settings), empty if authentication is disabled
+	Password string `json:"password,omitempty"`
+
+	// ReconnectMs is the time between connection attempts, defaults to DefaultReconnectMs
+	// A negative value disables reconnecting, only one attempt is made on startup
+	ReconnectMs int `json:"reconnectMs,omitempty"`
+}
+
+// Status of the OBS connection
+// @Description State of the obs-websocket connection
+type Status struct {
+	// Configured is false if config.json has no OBS connection
+	Configured bool `json:"configured" example:"true"`
+
+	Connected bool   `json:"connected" example:"true"`
+	URL       string `json:"url,omitempty" example:"ws://localhost:4455"`
+
+	// Error is the reason of the last failed connection attempt or disconnect
+	Error string `json:"error,omitempty" example:"dial tcp 127.0.0.1:4455: connect: connection refused"`
+}
+
+// Message envelope of obs-websocket v5
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+// Data of the Hello message
+type hello struct {
+	RPCVersion     int `json:"rpcVersion"`
+	Authentication *struct {
+		Challenge string `json:"challenge"`
+		Salt      string `json:"salt"`
+	} `json:"authentication"`
+}
+
+// Data of the RequestResponse message
+type response struct {
+	RequestID     string `json:"requestId"`
+	RequestStatus struct {
+		Result  bool   `json:"result"`
+		Code    int    `json:"code"`
+		Comment string `json:"comment"`
+	} `json:"requestStatus"`
+	ResponseData json.RawMessage `json:"responseData"`
+}
+
+// The connection, supervised in the background
+var (
+	stop    chan struct{} // nil if not started
+	conn    *websocket.Conn
+	status  Status
+	pending = make(map[string]chan response) // request ID -> waiting Request call
+	nextID  int
+	mu      sync.Mutex
+
+	writeMu sync.Mutex // one writer at a time
+)
+
+// Connect to OBS and keep reconnecting until Stop
+// Does nothing if the connection is not configured or already started
+func Start(c *Config) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c == nil || stop != nil {
+		return
+	}
+
+	config := *c
+	if config.URL == "" {
+		config.URL = DefaultURL
+	}
+	if config.ReconnectMs == 0 {
+		config.ReconnectMs = DefaultReconnectMs
+	}
+
+	stop = make(chan struct{})
+	status = Status{Configured: true, URL: config.URL}
+	go supervise(config, stop)
+}
+
+// Close the connection, Start may connect again afterwards
+// Called on shutdown
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	stop = nil
+	if conn != nil {
+		conn.Close()
+		conn = nil
+	}
+	status.Connected = false
+}
+
+// Returns the state of the connection
+func GetStatus() Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return status
+}
+
+// Connect, serve the connection and reconnect after it is lost
+func supervise(config Config, stop chan struct{}) {
+	lastError := ""
+	for {
+		connected, err := run(config, stop)
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		mu.Lock()
+		status.Connected = false
+		status.Error = err.Error()
+		mu.Unlock()
+
+		// A stopped OBS fails every attempt, only log changes
+		if connected {
+			Log.WriteToLogFile(fmt.Sprintf("OBS connection lost: %v", err))
+			lastError = ""
+		} else if err.Error() != lastError {
+			Log.WriteToLogFile(fmt.Sprintf("OBS connection failed: %v", err))
+			lastError = err.Error()
+		}
+
+		if config.ReconnectMs < 0 {
+			return
+		}
+		select {
+		case <-time.After(time.Duration(config.ReconnectMs) * time.Millisecond):
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Connect, identify and read responses until the connection is lost
+// Returns true if the connection was established before it failed
+func run(config Config, stop chan struct{}) (bool, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: requestTimeout}
+	c, _, err := dialer.Dial(config.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+
+	if err := identify(c, config.Password); err != nil {
+		return false, err
+	}
+
+	mu.Lock()
+	select {
+	case <-stop:
+		mu.Unlock()
+		return false, nil
+	default:
+	}
+	conn = c
+	status.Connected = true
+	status.Error = ""
+	mu.Unlock()
+
+	Log.WriteToLogFile("Connected to OBS at " + config.URL + ".")
+
+	err = readResponses(c)
+
+	mu.Lock()
+	if conn == c {
+		conn = nil
+	}
+	for id, ch := range pending {
+		close(ch)
+		delete(pending, id)
+	}
+	mu.Unlock()
+
+	return true, err
+}
+
+// Helper: answer the Hello message with Identify and wait for Identified
+func identify(c *websocket.Conn, password string) error {
+	c.SetReadDeadline(time.Now().Add(requestTimeout))
+	defer c.SetReadDeadline(time.Time{})
+
+	var msg message
+	if err := c.ReadJSON(&msg); err != nil {
+		return err
+	}
+	if msg.Op != opHello {
+		return fmt.Errorf("unexpected message (op %d) instead of Hello", msg.Op)
+	}
+	var h hello
+	if err := json.Unmarshal(msg.D, &h); err != nil {
+		return err
+	}
+
+	identify := map[string]any{"rpcVersion": 1, "eventSubscriptions": 0}
+	if h.Authentication != nil {
+		if password == "" {
+			return errors.New("OBS requires a password")
+		}
+		identify["authentication"] = authenticate(password, h.Authentication.Salt, h.Authentication.Challenge)
+	}
+	if err := writeMessage(c, opIdentify, identify); err != nil {
+		return err
+	}
+
+	// A wrong password closes the connection (close code 4009)
+	if err := c.ReadJSON(&msg); err != nil {
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) && closeErr.Code == 4009 {
+			return errors.New("wrong OBS password")
+		}
+		return err
+	}
+	if msg.Op != opIdentified {
+		return fmt.Errorf("unexpected message (op %d) instead of Identified", msg.Op)
+	}
+	return nil
+}
+
+// Helper: the authentication string of obs-websocket v5
+// base64(sha256(base64(sha256(password + salt)) + challenge))
+func authenticate(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+// Helper: hand request responses to the waiting Request calls, other messages are ignored
+func readResponses(c *websocket.Conn) error {
+	for {
+		var msg message
+		if err := c.ReadJSON(&msg); err != nil {
+			return err
+		}
+		if msg.Op != opRequestResponse {
+			continue
+		}
+
+		var resp response
+		if err := json.Unmarshal(msg.D, &resp); err != nil {
+			continue
+		}
+
+		mu.Lock()
+		if ch, ok := pending[resp.RequestID]; ok {
+			ch <- resp
+			delete(pending, resp.RequestID)
+		}
+		mu.Unlock()
+	}
+}
+
+// Helper: write a message
+func writeMessage(c *websocket.Conn, op int, data any) error {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	c.SetWriteDeadline(time.Now().Add(requestTimeout))
+	return c.WriteJSON(message{Op: op, D: d})
+}
+
+// Send a request (see the obs-websocket protocol docs) and wait for its response data
+func Request(requestType string, data map[string]any) (json.RawMessage, error) {
+	mu.Lock()
+	c := conn
+	if c == nil {
+		mu.Unlock()
+		return nil, ErrNotConnected
+	}
+	nextID++
+	id := strconv.Itoa(nextID)
+	ch := make(chan response, 1)
+	pending[id] = ch
+	mu.Unlock()
+
+	request := map[string]any{"requestType": requestType, "requestId": id}
+	if data != nil {
+		request["requestData"] = data
+	}
+	if err := writeMessage(c, opRequest, request); err != nil {
+		mu.Lock()
+		delete(pending, id)
+		mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrNotConnected
+		}
+		if !resp.RequestStatus.Result {
+			return nil, fmt.Errorf("%s failed: %s (code %d)", requestType, resp.RequestStatus.Comment, resp.RequestStatus.Code)
+		}
+		return resp.ResponseData, nil
+
+	case <-time.After(requestTimeout):
+		mu.Lock()
+		delete(pending, id)
+		mu.Unlock()
+		return nil, fmt.Errorf("%s timed out", requestType)
+	}
+}
@@ -0,0 +1,299 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// A stand-in for the obs-websocket v5 server of OBS
+type mockOBS struct {
+	t        *testing.T
+	server   *httptest.Server
+	password string // empty disables authentication
+
+	// Answers a request with its status and response data
+	handle func(requestType string, data map[string]any) (bool, any)
+
+	// Closes connections right after identifying them, until cleared
+	dropConnections bool
+
+	mu          sync.Mutex
+	connections int
+	requests    []string
+}
+
+// Helper: start a mock server, it is closed at the end of the test
+func newMockOBS(t *testing.T, password string) *mockOBS {
+	t.Helper()
+
+	m := &mockOBS{t: t, password: password}
+	m.handle = func(string, map[string]any) (bool, any) { return true, nil }
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// Helper: config of a connection to the mock server
+func (m *mockOBS) config(reconnectMs int) *Config {
+	return &Config{
+		URL:         "ws" + strings.TrimPrefix(m.server.URL, "http"),
+		Password:    m.password,
+		ReconnectMs: reconnectMs,
+	}
+}
+
+func (m *mockOBS) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	// Hello, with a challenge if a password is set
+	const salt, challenge = "lM1GncleQOaCu9lT1yeUZhFYnqhsLLP1G5lAGo3ixaI=", "+IxH4CnCiqpX1rM9scsNynZzbOe4KhDeYcTNS3PDaeY="
+	hello := map[string]any{"obsWebSocketVersion": "5.0.0", "rpcVersion": 1}
+	if m.password != "" {
+		hello["authentication"] = map[string]string{"challenge": challenge, "salt": salt}
+	}
+	m.write(c, opHello, hello)
+
+	var msg message
+	if err := c.ReadJSON(&msg); err != nil || msg.Op != opIdentify {
+		return
+	}
+	var identify struct {
+		RPCVersion     int    `json:"rpcVersion"`
+		Authentication string `json:"authentication"`
+	}
+	json.Unmarshal(msg.D, &identify)
+
+	if m.password != "" {
+		secret := sha256.Sum256([]byte(m.password + salt))
+		auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+		if identify.Authentication != base64.StdEncoding.EncodeToString(auth[:]) {
+			c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4009, "Authentication failed."))
+			return
+		}
+	}
+	m.write(c, opIdentified, map[string]any{"negotiatedRpcVersion": identify.RPCVersion})
+
+	m.mu.Lock()
+	m.connections++
+	drop := m.dropConnections
+	m.mu.Unlock()
+	if drop {
+		return
+	}
+
+	for {
+		if err := c.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Op != opRequest {
+			continue
+		}
+
+		var request struct {
+			RequestType string         `json:"requestType"`
+			RequestID   string         `json:"requestId"`
+			RequestData map[string]any `json:"requestData"`
+		}
+		json.Unmarshal(msg.D, &request)
+
+		m.mu.Lock()
+		m.requests = append(m.requests, request.RequestType)
+		m.mu.Unlock()
+
+		result, data := m.handle(request.RequestType, request.RequestData)
+		requestStatus := map[string]any{"result": result, "code": 100}
+		if !result {
+			requestStatus = map[string]any{"result": false, "code": 600, "comment": "No source was found."}
+		}
+		m.write(c, opRequestResponse, map[string]any{
+			"requestType":   request.RequestType,
+			"requestId":     request.RequestID,
+			"requestStatus": requestStatus,
+			"responseData":  data,
+		})
+	}
+}
+
+// Helper: write a message to the client
+func (m *mockOBS) write(c *websocket.Conn, op int, data any) {
+	d, _ := json.Marshal(data)
+	if err := c.WriteJSON(message{Op: op, D: d}); err != nil {
+		m.t.Errorf("mock OBS: %v", err)
+	}
+}
+
+// Helper: the number of identified connections
+func (m *mockOBS) connectionCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connections
+}
+
+// Helper: start the connection and stop it at the end of the test
+func startOBS(t *testing.T, c *Config) {
+	t.Helper()
+	Start(c)
+	t.Cleanup(Stop)
+}
+
+// Helper: wait until the condition holds
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s (status %+v)", what, GetStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRequestResponse(t *testing.T) {
+	mock := newMockOBS(t, "secret")
+	mock.handle = func(requestType string, data map[string]any) (bool, any) {
+		switch requestType {
+		case "GetVersion":
+			return true, map[string]any{"obsVersion": "30.0.0"}
+		case "SetCurrentProgramScene":
+			return data["sceneName"] == "Live", nil
+		}
+		return false, nil
+	}
+	startOBS(t, mock.config(-1))
+	waitFor(t, "the connection", func() bool { return GetStatus().Connected })
+
+	data, err := Request("GetVersion", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var version struct {
+		OBSVersion string `json:"obsVersion"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil || version.OBSVersion != "30.0.0" {
+		t.Errorf("GetVersion: got %s (%v)", data, err)
+	}
+
+	if err := SwitchScene("Live"); err != nil {
+		t.Errorf("SwitchScene: %v", err)
+	}
+
+	err = SwitchScene("Missing")
+	if err == nil || !strings.Contains(err.Error(), "No source was found.") || !strings.Contains(err.Error(), "code 600") {
+		t.Errorf("failed request: got error %v", err)
+	}
+}
+
+func TestToggleSource(t *testing.T) {
+	mock := newMockOBS(t, "")
+	var enabled any
+	mock.handle = func(requestType string, data map[string]any) (bool, any) {
+		switch requestType {
+		case "GetCurrentProgramScene":
+			return true, map[string]any{"sceneName": "Live"}
+		case "GetSceneItemId":
+			return data["sceneName"] == "Live" && data["sourceName"] == "Camera", map[string]any{"sceneItemId": 7}
+		case "GetSceneItemEnabled":
+			return data["sceneItemId"] == 7.0, map[string]any{"sceneItemEnabled": true}
+		case "SetSceneItemEnabled":
+			enabled = data["sceneItemEnabled"]
+			return data["sceneItemId"] == 7.0, nil
+		}
+		return false, nil
+	}
+	startOBS(t, mock.config(-1))
+	waitFor(t, "the connection", func() bool { return GetStatus().Connected })
+
+	if err := ToggleSource("", "Camera"); err != nil {
+		t.Fatal(err)
+	}
+	if enabled != false {
+		t.Errorf("sceneItemEnabled: got %v, want false", enabled)
+	}
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	want := []string{"GetCurrentProgramScene", "GetSceneItemId", "GetSceneItemEnabled", "SetSceneItemEnabled"}
+	if strings.Join(mock.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests: got %v, want %v", mock.requests, want)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	mock := newMockOBS(t, "secret")
+	c := mock.config(-1)
+	c.Password = "wrong"
+	startOBS(t, c)
+
+	waitFor(t, "the failed attempt", func() bool { return GetStatus().Error != "" })
+	if status := GetStatus(); status.Connected || status.Error != "wrong OBS password" {
+		t.Errorf("status: got %+v", status)
+	}
+}
+
+func TestMissingPassword(t *testing.T) {
+	mock := newMockOBS(t, "secret")
+	c := mock.config(-1)
+	c.Password = ""
+	startOBS(t, c)
+
+	waitFor(t, "the failed attempt", func() bool { return GetStatus().Error != "" })
+	if status := GetStatus(); status.Error != "OBS requires a password" {
+		t.Errorf("status: got %+v", status)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	mock := newMockOBS(t, "")
+	mock.dropConnections = true
+	startOBS(t, mock.config(20))
+
+	// Dropped connections are retried
+	waitFor(t, "a reconnect", func() bool { return mock.connectionCount() >= 2 })
+
+	mock.mu.Lock()
+	mock.dropConnections = false
+	mock.mu.Unlock()
+
+	waitFor(t, "a request to succeed", func() bool {
+		_, err := Request("GetVersion", nil)
+		return err == nil
+	})
+	if status := GetStatus(); !status.Connected || status.Error != "" {
+		t.Errorf("status: got %+v", status)
+	}
+}
+
+func TestNotConnected(t *testing.T) {
+	if _, err := Request("GetVersion", nil); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("got error %v, want ErrNotConnected", err)
+	}
+
+	// The connection is only attempted once with reconnecting disabled
+	mock := newMockOBS(t, "")
+	c := mock.config(-1)
+	mock.server.Close()
+	startOBS(t, c)
+
+	waitFor(t, "the failed attempt", func() bool { return GetStatus().Error != "" })
+	if status := GetStatus(); !status.Configured || status.Connected || status.URL != c.URL {
+		t.Errorf("status: got %+v", status)
+	}
+	if _, err := Request("GetVersion", nil); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("got error %v, want ErrNotConnected", err)
+	}
+}
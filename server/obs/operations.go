@@ -0,0 +1,70 @@
+package obs
+
+import (
+	"encoding/json"
+)
+
+// Switch the program scene
+func SwitchScene(scene string) error {
+	_, err := Request("SetCurrentProgramScene", map[string]any{"sceneName": scene})
+	return err
+}
+
+// Show a hidden source or hide a visible one
+// An empty scene means the current program scene
+func ToggleSource(scene, source string) error {
+	if scene == "" {
+		data, err := Request("GetCurrentProgramScene", nil)
+		if err != nil {
+			return err
+		}
+		var current struct {
+			SceneName string `json:"sceneName"`
+		}
+		if err := json.Unmarshal(data, &current); err != nil {
+			return err
+		}
+		scene = current.SceneName
+	}
+
+	data, err := Request("GetSceneItemId", map[string]any{"sceneName": scene, "sourceName": source})
+	if err != nil {
+		return err
+	}
+	var item struct {
+		SceneItemID int `json:"sceneItemId"`
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	data, err = Request("GetSceneItemEnabled", map[string]any{"sceneName": scene, "sceneItemId": item.SceneItemID})
+	if err != nil {
+		return err
+	}
+	var state struct {
+		SceneItemEnabled bool `json:"sceneItemEnabled"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	_, err = Request("SetSceneItemEnabled", map[string]any{
+		"sceneName":        scene,
+		"sceneItemId":      item.SceneItemID,
+		"sceneItemEnabled": !state.SceneItemEnabled,
+	})
+	return err
+}
+
+// Mute an unmuted input or unmute a muted one
+func ToggleMute(input string) error {
+	_, err := Request("ToggleInputMute", map[string]any{"inputName": input})
+	return err
+}
+
+// Trigger a hotkey by its name (eg. OBSBasic.Screenshot)
+func TriggerHotkey(name string) error {
+	_, err := Request("TriggerHotkeyByName", map[string]any{"hotkeyName": name})
+	return err
+}
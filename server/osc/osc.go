@@ -0,0 +1,248 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Message is an OSC 1.0 message
+// Supported argument types: int32 (i), int64 (h), float32 (f), float64 (d), string (s), []byte (b), bool (T/F) and nil (N)
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// Identifies a bundle, its messages are handled one after another (time tags are ignored)
+const bundleTag = "#bundle"
+
+// Encode the message into an OSC packet
+func (m Message) MarshalBinary() ([]byte, error) {
+	if !strings.HasPrefix(m.Address, "/") {
+		return nil, fmt.Errorf("invalid OSC address %q (must start with /)", m.Address)
+	}
+
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+
+	tags := []byte{','}
+	var args bytes.Buffer
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			binary.Write(&args, binary.BigEndian, v)
+		case int64:
+			tags = append(tags, 'h')
+			binary.Write(&args, binary.BigEndian, v)
+		case float32:
+			tags = append(tags, 'f')
+			binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case float64:
+			tags = append(tags, 'd')
+			binary.Write(&args, binary.BigEndian, math.Float64bits(v))
+		case string:
+			tags = append(tags, 's')
+			writeString(&args, v)
+		case []byte:
+			tags = append(tags, 'b')
+			binary.Write(&args, binary.BigEndian, int32(len(v)))
+			args.Write(v)
+			args.Write(make([]byte, padding(len(v))))
+		case bool:
+			if v {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		case nil:
+			tags = append(tags, 'N')
+		default:
+			return nil, fmt.Errorf("unsupported OSC argument type %T", arg)
+		}
+	}
+
+	writeString(&buf, string(tags))
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
+
+// Decode an OSC packet, a message or a bundle of messages
+func Parse(data []byte) ([]Message, error) {
+	if bytes.HasPrefix(data, []byte(bundleTag+"\x00")) {
+		return parseBundle(data)
+	}
+
+	msg, err := parseMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	return []Message{msg}, nil
+}
+
+// Helper: decode the elements of a bundle, nested bundles included
+func parseBundle(data []byte) ([]Message, error) {
+	r := reader{data: data}
+	if err := r.skip(len(bundleTag) + 1 + 8); err != nil { // tag and time tag
+		return nil, err
+	}
+
+	var messages []Message
+	for r.pos < len(r.data) {
+		size, err := r.int32()
+		if err != nil {
+			return nil, err
+		}
+		element, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := Parse(element)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, parsed...)
+	}
+	return messages, nil
+}
+
+// Helper: decode a message
+func parseMessage(data []byte) (Message, error) {
+	r := reader{data: data}
+
+	address, err := r.string()
+	if err != nil {
+		return Message{}, err
+	}
+	if !strings.HasPrefix(address, "/") {
+		return Message{}, fmt.Errorf("invalid OSC address %q", address)
+	}
+	msg := Message{Address: address}
+
+	// Very old implementations omit the type tags, such messages have no arguments
+	if r.pos >= len(r.data) {
+		return msg, nil
+	}
+	tags, err := r.string()
+	if err != nil {
+		return Message{}, err
+	}
+	if !strings.HasPrefix(tags, ",") {
+		return Message{}, errors.New("missing OSC type tags")
+	}
+
+	for _, tag := range tags[1:] {
+		var arg any
+		switch tag {
+		case 'i':
+			arg, err = r.int32()
+		case 'h':
+			var v uint64
+			v, err = r.uint64()
+			arg = int64(v)
+		case 'f':
+			var v int32
+			v, err = r.int32()
+			arg = math.Float32frombits(uint32(v))
+		case 'd':
+			var v uint64
+			v, err = r.uint64()
+			arg = math.Float64frombits(v)
+		case 's', 'S':
+			arg, err = r.string()
+		case 'b':
+			var size int32
+			if size, err = r.int32(); err == nil {
+				var blob []byte
+				if blob, err = r.bytes(int(size)); err == nil {
+					err = r.skip(padding(int(size)))
+				}
+				arg = blob
+			}
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N', 'I':
+			arg = nil
+		default:
+			return Message{}, fmt.Errorf("unsupported OSC type tag %q", tag)
+		}
+		if err != nil {
+			return Message{}, err
+		}
+		msg.Args = append(msg.Args, arg)
+	}
+
+	return msg, nil
+}
+
+// Helper: write a null terminated string padded to 4 bytes
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.Write(make([]byte, 4-len(s)%4))
+}
+
+// Helper: bytes needed to pad n bytes to a multiple of 4
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
+
+// Reads the big-endian, 4 byte aligned fields of a packet
+type reader struct {
+	data []byte
+	pos  int
+}
+
+var errShort = errors.New("truncated OSC packet")
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errShort
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) int32() (int32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (r *reader) uint64() (uint64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (r *reader) skip(n int) error {
+	_, err := r.bytes(n)
+	return err
+}
+
+func (r *reader) string() (string, error) {
+	if r.pos >= len(r.data) {
+		return "", errShort
+	}
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		return "", errShort
+	}
+	s := string(r.data[r.pos : r.pos+end])
+
+	// The padding has to be in the packet too
+	if err := r.skip(end + 1 + padding(end+1)); err != nil {
+		return "", err
+	}
+	return s, nil
+}
@@ -0,0 +1,198 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalSpecExample(t *testing.T) {
+	// Example from the OSC 1.0 specification
+	want := []byte("/oscillator/4/frequency\x00,f\x00\x00\x43\xdc\x00\x00")
+
+	got, err := Message{Address: "/oscillator/4/frequency", Args: []any{float32(440)}}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	messages := []Message{
+		{Address: "/a"},
+		{Address: "/abc", Args: []any{int32(1000), int32(-1)}},
+		{Address: "/foo/bar", Args: []any{"hello", "", float32(1.234), 5.678}},
+		{Address: "/blob", Args: []any{[]byte{1, 2, 3}, []byte{1, 2, 3, 4}, []byte{}}},
+		{Address: "/flags", Args: []any{true, false, nil, int64(-7)}},
+	}
+
+	for _, msg := range messages {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", msg.Address, err)
+		}
+		if len(data)%4 != 0 {
+			t.Errorf("%s: packet size %d is not a multiple of 4", msg.Address, len(data))
+		}
+
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: %v", msg.Address, err)
+		}
+		if len(parsed) != 1 || !reflect.DeepEqual(parsed[0], msg) {
+			t.Errorf("%s: got %#v, want %#v", msg.Address, parsed, msg)
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	if _, err := (Message{Address: "a"}).MarshalBinary(); err == nil {
+		t.Error("address without / accepted")
+	}
+	if _, err := (Message{Address: "/a", Args: []any{1}}).MarshalBinary(); err == nil {
+		t.Error("int argument accepted")
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	data, err := Message{Address: "/foo", Args: []any{int32(1), "hello", []byte{1, 2, 3}, 2.5}}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every prefix has to fail without panicking
+	// Except the bare address, a message without type tags is valid
+	for n := range len(data) {
+		if n == len("/foo\x00\x00\x00\x00") {
+			continue
+		}
+		if _, err := Parse(data[:n]); err == nil {
+			t.Errorf("prefix of %d bytes accepted", n)
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	packets := []string{
+		"/a\x00\x00,ss\x00x\x00",                           // string padding past the end
+		"/a\x00\x00,b\x00\x00\x00\x00\x00\x03\x01\x02\x03", // blob padding past the end
+		"/a\x00\x00,b\x00\x00\xff\xff\xff\xff",             // negative blob size
+		"/a\x00\x00,x\x00\x00",                             // unknown type tag
+		"/a\x00\x00i\x00\x00\x00",                          // type tags without comma
+		"a\x00\x00\x00",                                    // address without /
+		"/a",                                               // unterminated address
+		"#bundle\x00\x00\x00",                              // truncated time tag
+		"#bundle\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x10", // element size past the end
+	}
+
+	for _, packet := range packets {
+		if _, err := Parse([]byte(packet)); err == nil {
+			t.Errorf("%q accepted", packet)
+		}
+	}
+}
+
+// Helper: build a bundle of OSC packets
+func bundle(elements ...[]byte) []byte {
+	data := append([]byte(bundleTag+"\x00"), make([]byte, 8)...)
+	for _, element := range elements {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(element)))
+		data = append(data, element...)
+	}
+	return data
+}
+
+func TestBundle(t *testing.T) {
+	first, _ := Message{Address: "/first", Args: []any{int32(1)}}.MarshalBinary()
+	second, _ := Message{Address: "/second"}.MarshalBinary()
+	third, _ := Message{Address: "/third", Args: []any{"x"}}.MarshalBinary()
+
+	messages, err := Parse(bundle(first, bundle(second, third)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Message{
+		{Address: "/first", Args: []any{int32(1)}},
+		{Address: "/second"},
+		{Address: "/third", Args: []any{"x"}},
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("got %#v, want %#v", messages, want)
+	}
+
+	if messages, err := Parse(bundle()); err != nil || len(messages) != 0 {
+		t.Errorf("empty bundle: got %v, %v", messages, err)
+	}
+}
+
+func TestSendListen(t *testing.T) {
+	received := make(chan Message, 1)
+	failed := make(chan error, 1)
+
+	conn, err := Listen("127.0.0.1:0", func(msg Message) { received <- msg }, func(err error) { failed <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	target := conn.LocalAddr().String()
+
+	msg := Message{Address: "/pedal/1", Args: []any{float32(1)}}
+	if err := Send(target, msg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if !reflect.DeepEqual(got, msg) {
+			t.Errorf("got %#v, want %#v", got, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+
+	// A malformed packet is reported and the listener keeps running
+	if err := sendRaw(target, []byte("/a\x00\x00,ss\x00x\x00")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("malformed packet not reported")
+	}
+
+	if err := Send(target, msg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("listener stopped after a malformed packet")
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	data, _ := Message{Address: "/foo", Args: []any{int32(1), "hello", []byte{1}, true}}.MarshalBinary()
+	f.Add(data)
+	f.Add(bundle(data))
+	f.Add([]byte("/a\x00\x00,ss\x00x\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data) // must not panic
+	})
+}
+
+// Helper: send a raw packet
+func sendRaw(target string, data []byte) error {
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(data)
+	return err
+}
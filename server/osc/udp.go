@@ -0,0 +1,61 @@
+package osc
+
+import (
+	"net"
+	"time"
+)
+
+// Largest packet read by Listen
+const maxPacketSize = 65507
+
+// Time a send may take
+const sendTimeout = time.Second
+
+// Send a message over UDP to a host:port
+func Send(target string, msg Message) error {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("udp", target, sendTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+	_, err = conn.Write(data)
+	return err
+}
+
+// Listen for OSC packets on a UDP address (eg. :9000) and pass their messages to handle
+// Returns once the socket is open, packets are read in the background until the connection is closed
+// Invalid packets are passed to onError
+func Listen(addr string, handle func(Message), onError func(error)) (net.PacketConn, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return // closed
+			}
+
+			messages, err := Parse(buf[:n])
+			if err != nil {
+				onError(err)
+				continue
+			}
+			for _, msg := range messages {
+				handle(msg)
+			}
+		}
+	}()
+
+	return conn, nil
+}
@@ -0,0 +1,55 @@
+package pedal
+
+import (
+	"time"
+)
+
+// What happens when a command pedal is pressed while its previous run is still running
+type CommandPolicy string
+
+const (
+	PolicySkip  CommandPolicy = "skip"  // ignore the press
+	PolicyQueue CommandPolicy = "queue" // run again after the previous run finished
+	PolicyKill  CommandPolicy = "kill"  // kill the previous run and start a new one
+)
+
+// All command policies, in the order they are listed in validation errors
+var policies = []CommandPolicy{PolicySkip, PolicyQueue, PolicyKill}
+
+// Default time a command may run before it is killed
+const DefaultCommandTimeoutMs = 30000
+
+// CommandAction describes a program run by a command pedal
+type CommandAction struct {
+	// Program is the executable to run (resolved using PATH)
+	// With Shell set, this is the command line passed to the shell
+	Program string `json:"program" example:"git"`
+
+	// Args are passed to the program as is, no shell is involved
+	Args []string `json:"args,omitempty" example:"stash"`
+
+	// Shell runs Program using the system shell (sh -c or cmd /C)
+	Shell bool `json:"shell,omitempty" example:"false"`
+
+	// Dir is the working directory, defaults to the StepKeys directory
+	Dir string `json:"dir,omitempty" example:"/home/user/project"`
+
+	// Env holds extra environment variables, added to the environment of StepKeys
+	Env map[string]string `json:"env,omitempty"`
+
+	// TimeoutMs is the time the program may run before it is killed
+	// Defaults to DefaultCommandTimeoutMs
+	TimeoutMs int `json:"timeoutMs,omitempty" example:"30000"`
+
+	// Policy defines what happens on a press while the previous run is still running
+	// Defaults to skip
+	Policy CommandPolicy `json:"policy,omitempty" example:"skip"`
+}
+
+// Returns the timeout with the default applied
+func (c CommandAction) Timeout() time.Duration {
+	if c.TimeoutMs > 0 {
+		return time.Duration(c.TimeoutMs) * time.Millisecond
+	}
+	return DefaultCommandTimeoutMs * time.Millisecond
+}
@@ -0,0 +1,26 @@
+package pedal
+
+// Gamepad buttons that can be used by the gamepad mode (Xbox layout)
+var ValidGamepadButtons = []string{
+	"a", "b", "x", "y",
+	"lb", "rb", "back", "start", "guide", "lstick", "rstick",
+	"dpad_up", "dpad_down", "dpad_left", "dpad_right",
+}
+
+// Gamepad axes that can be used by the gamepad mode
+// Sticks range from -1 to 1, triggers (lt, rt) from 0 to 1
+var ValidGamepadAxes = []string{"left_x", "left_y", "right_x", "right_y", "lt", "rt"}
+
+// Checks if the gamepad axis is a trigger
+func IsGamepadTrigger(axis string) bool {
+	return axis == "lt" || axis == "rt"
+}
+
+// Checks if any action of the pedal map (nested actions included) uses the gamepad mode
+func UsesGamepad(pedalMap PedalMap) bool {
+	uses := false
+	walkActions(pedalMap, func(action PedalAction) {
+		uses = uses || action.Mode == Gamepad
+	})
+	return uses
+}
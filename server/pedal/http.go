@@ -0,0 +1,83 @@
+package pedal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// HTTP methods accepted by http pedals, in the order they are listed in validation errors
+var httpMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Default time an HTTP request may take
+const DefaultHTTPTimeoutMs = 5000
+
+// HTTPRequest describes a request sent by an http pedal
+type HTTPRequest struct {
+	// Method is the HTTP method, defaults to POST
+	Method string `json:"method,omitempty" example:"POST"`
+
+	// URL is the http or https URL the request is sent to
+	URL string `json:"url" example:"http://localhost:8123/api/webhook/desk-lamp"`
+
+	// Headers are added to the request
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Body is a JSON body template (Go text/template syntax)
+	// {{.Event}} is "press" or "release", {{.Time}} is the RFC 3339 time of the event
+	Body string `json:"body,omitempty" example:"{\"event\": \"{{.Event}}\"}"`
+
+	// TimeoutMs is the time the request may take, defaults to DefaultHTTPTimeoutMs
+	TimeoutMs int `json:"timeoutMs,omitempty" example:"5000"`
+
+	// OnRelease sends the request on pedal release too (oneshot behaviour only)
+	OnRelease bool `json:"onRelease,omitempty" example:"false"`
+}
+
+// Data available in HTTP body templates
+type httpBodyData struct {
+	Event string
+	Time  string
+}
+
+// Returns the method with the default applied
+func (r HTTPRequest) HTTPMethod() string {
+	if r.Method == "" {
+		return http.MethodPost
+	}
+	return r.Method
+}
+
+// Returns the timeout with the default applied
+func (r HTTPRequest) Timeout() time.Duration {
+	if r.TimeoutMs > 0 {
+		return time.Duration(r.TimeoutMs) * time.Millisecond
+	}
+	return DefaultHTTPTimeoutMs * time.Millisecond
+}
+
+// Render the body template for a pedal event ("press" or "release")
+// The result must be valid JSON, an empty template renders an empty body
+func (r HTTPRequest) RenderBody(event string, at time.Time) ([]byte, error) {
+	if r.Body == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("body").Option("missingkey=error").Parse(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, httpBodyData{Event: event, Time: at.Format(time.RFC3339)}); err != nil {
+		return nil, err
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("body is not valid JSON")
+	}
+
+	return body.Bytes(), nil
+}
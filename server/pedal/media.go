@@ -0,0 +1,49 @@
+package pedal
+
+import (
+	"time"
+)
+
+// Media player operations of the media mode
+const (
+	MediaPlayPause = "playPause"
+	MediaPlay      = "play"
+	MediaPause     = "pause"
+	MediaSeek      = "seek"
+	MediaRate      = "rate"
+	MediaNext      = "next"
+	MediaPrevious  = "previous"
+)
+
+// All media operations, in the order they are listed in validation errors
+var MediaOperations = []string{MediaPlayPause, MediaPlay, MediaPause, MediaSeek, MediaRate, MediaNext, MediaPrevious}
+
+// MediaAction controls a media player through MPRIS (Linux only)
+type MediaAction struct {
+	// Operation is what the pedal does with the player
+	// play is the only operation that can be held: the player plays while the pedal is held or latched
+	Operation string `json:"operation" example:"playPause"`
+
+	// Player selects the player by a case-insensitive substring of its bus or display name (eg. vlc)
+	// If empty (or several players match), a playing player is preferred
+	Player string `json:"player,omitempty" example:"vlc"`
+
+	// Seconds is the offset of the seek operation, negative values seek backward
+	Seconds float64 `json:"seconds,omitempty" example:"-5"`
+
+	// Rate is the playback rate set by the rate operation, 1 is the normal speed
+	Rate float64 `json:"rate,omitempty" example:"0.75"`
+
+	// RewindOnResume rewinds the player by this many seconds when the play and playPause operations resume playback
+	RewindOnResume float64 `json:"rewindOnResume,omitempty" example:"2"`
+}
+
+// Returns the seek offset
+func (m MediaAction) Offset() time.Duration {
+	return time.Duration(m.Seconds * float64(time.Second))
+}
+
+// Returns the rewind applied when playback resumes
+func (m MediaAction) Rewind() time.Duration {
+	return time.Duration(m.RewindOnResume * float64(time.Second))
+}
@@ -0,0 +1,45 @@
+package pedal
+
+import (
+	"time"
+)
+
+// Mouse buttons that can be used by the click modes
+var MouseButtons = []string{"left", "right", "middle"}
+
+// Directions that can be used by the scroll and mouseMove modes
+var Directions = []string{"up", "down", "left", "right"}
+
+// Mouse pointer movement defaults (pixels per second)
+const (
+	DefaultMotionSpeed    = 200
+	DefaultMotionMaxSpeed = 3000
+)
+
+// MouseMotion configures the speed of a mouseMove pedal
+// The speed grows linearly from Speed by Acceleration every second the pedal is held, up to MaxSpeed
+type MouseMotion struct {
+	// Speed is the starting speed in pixels per second, defaults to DefaultMotionSpeed
+	Speed int `json:"speed" example:"200"`
+
+	// Acceleration is added to the speed every second in pixels per second, 0 means constant speed
+	Acceleration int `json:"acceleration" example:"800"`
+
+	// MaxSpeed caps the speed in pixels per second, defaults to DefaultMotionMaxSpeed
+	MaxSpeed int `json:"maxSpeed" example:"2000"`
+}
+
+// Returns the speed in pixels per second after the pedal was held for the given duration
+func (m MouseMotion) SpeedAfter(held time.Duration) float64 {
+	speed := float64(m.Speed)
+	if m.Speed == 0 {
+		speed = DefaultMotionSpeed
+	}
+
+	maxSpeed := float64(m.MaxSpeed)
+	if m.MaxSpeed == 0 {
+		maxSpeed = DefaultMotionMaxSpeed
+	}
+
+	return min(speed+float64(m.Acceleration)*held.Seconds(), maxSpeed)
+}
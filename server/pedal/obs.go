@@ -0,0 +1,41 @@
+package pedal
+
+// OBS operations of the obs mode
+const (
+	OBSScene        = "scene"
+	OBSToggleSource = "toggleSource"
+	OBSToggleMute   = "toggleMute"
+	OBSHotkey       = "hotkey"
+	OBSStartRecord  = "startRecord"
+	OBSStopRecord   = "stopRecord"
+	OBSToggleRecord = "toggleRecord"
+	OBSStartStream  = "startStream"
+	OBSStopStream   = "stopStream"
+	OBSToggleStream = "toggleStream"
+)
+
+// All OBS operations, in the order they are listed in validation errors
+var OBSOperations = []string{
+	OBSScene, OBSToggleSource, OBSToggleMute, OBSHotkey,
+	OBSStartRecord, OBSStopRecord, OBSToggleRecord, OBSStartStream, OBSStopStream, OBSToggleStream,
+}
+
+// OBSAction controls OBS Studio through obs-websocket
+// The connection is configured in config.json
+type OBSAction struct {
+	// Operation is what the pedal does in OBS
+	Operation string `json:"operation" example:"scene"`
+
+	// Scene is the scene switched to by the scene operation
+	// The toggleSource operation uses it too, defaulting to the current program scene
+	Scene string `json:"scene,omitempty" example:"Camera"`
+
+	// Source is shown or hidden by the toggleSource operation
+	Source string `json:"source,omitempty" example:"Webcam"`
+
+	// Input is muted or unmuted by the toggleMute operation
+	Input string `json:"input,omitempty" example:"Mic/Aux"`
+
+	// Hotkey is the name of the hotkey triggered by the hotkey operation
+	Hotkey string `json:"hotkey,omitempty" example:"OBSBasic.Screenshot"`
+}
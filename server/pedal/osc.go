@@ -0,0 +1,112 @@
+package pedal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// OSC argument type tags accepted by osc pedals, in the order they are listed in validation errors
+// i: int32, h: int64, f: float32, d: float64, s: string, T: true, F: false, N: nil
+var OSCArgTypes = []string{"i", "h", "f", "d", "s", "T", "F", "N"}
+
+// OSCAction describes the OSC messages sent by an osc pedal
+type OSCAction struct {
+	// Target is the host:port the messages are sent to over UDP
+	Target string `json:"target" example:"127.0.0.1:8000"`
+
+	// Address is the OSC address of the message sent on press
+	Address string `json:"address" example:"/transport/play"`
+
+	// Args are the typed arguments of the message sent on press
+	Args []OSCArg `json:"args,omitempty"`
+
+	// OnRelease sends a message on pedal release too (oneshot behaviour only)
+	OnRelease bool `json:"onRelease,omitempty" example:"false"`
+
+	// ReleaseAddress is the OSC address of the release message, defaults to Address
+	ReleaseAddress string `json:"releaseAddress,omitempty" example:"/transport/stop"`
+
+	// ReleaseArgs are the typed arguments of the release message
+	ReleaseArgs []OSCArg `json:"releaseArgs,omitempty"`
+}
+
+// OSCArg is a typed OSC argument
+type OSCArg struct {
+	// Type is the OSC type tag, see OSCArgTypes
+	Type string `json:"type" example:"f"`
+
+	// Value is a number for i, h, f and d, a string for s, and is ignored for T, F and N
+	Value any `json:"value,omitempty"`
+}
+
+// Returns the address and the arguments of the message sent on press
+func (o OSCAction) PressMessage() (string, []any, error) {
+	return oscMessage(o.Address, o.Args)
+}
+
+// Returns the address and the arguments of the message sent on release
+func (o OSCAction) ReleaseMessage() (string, []any, error) {
+	address := o.ReleaseAddress
+	if address == "" {
+		address = o.Address
+	}
+	return oscMessage(address, o.ReleaseArgs)
+}
+
+// Helper: check the address and convert the typed arguments
+func oscMessage(address string, args []OSCArg) (string, []any, error) {
+	if !strings.HasPrefix(address, "/") {
+		return "", nil, fmt.Errorf("invalid OSC address %q (must start with /)", address)
+	}
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		value, err := arg.convert()
+		if err != nil {
+			return "", nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		values[i] = value
+	}
+	return address, values, nil
+}
+
+// Helper: convert the JSON value to the Go type of the type tag
+func (a OSCArg) convert() (any, error) {
+	switch a.Type {
+	case "i", "h":
+		n, ok := a.Value.(float64) // JSON numbers
+		if !ok || n != math.Trunc(n) {
+			return nil, fmt.Errorf("type %q needs an integer value", a.Type)
+		}
+		if a.Type == "h" {
+			return int64(n), nil
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("value %g is out of the int32 range", n)
+		}
+		return int32(n), nil
+	case "f", "d":
+		n, ok := a.Value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("type %q needs a number value", a.Type)
+		}
+		if a.Type == "f" {
+			return float32(n), nil
+		}
+		return n, nil
+	case "s":
+		s, ok := a.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("type %q needs a string value", a.Type)
+		}
+		return s, nil
+	case "T":
+		return true, nil
+	case "F":
+		return false, nil
+	case "N":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("invalid type %q (use %s)", a.Type, formatOptions(OSCArgTypes))
+}
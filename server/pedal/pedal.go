@@ -1,7 +1,7 @@
 package pedal
 
 import (
-	"fmt"
+	"time"
 )
 
 // Pedal mode
@@ -10,6 +10,37 @@ type PedalMode string
 const (
 	Sequence PedalMode = "sequence"
 	Combo    PedalMode = "combo"
+
+	Click       PedalMode = "click"
+	DoubleClick PedalMode = "doubleClick"
+	Scroll      PedalMode = "scroll"
+	MouseMove   PedalMode = "mouseMove"
+
+	Command PedalMode = "command"
+
+	OpenURL     PedalMode = "openUrl"
+	LaunchApp   PedalMode = "launchApp"
+	FocusWindow PedalMode = "focusWindow"
+
+	HTTP PedalMode = "http"
+
+	Text  PedalMode = "text"
+	Paste PedalMode = "paste"
+
+	Script PedalMode = "script"
+	Plugin PedalMode = "plugin"
+
+	Gamepad PedalMode = "gamepad"
+
+	Media PedalMode = "media"
+	OBS   PedalMode = "obs"
+	OSC   PedalMode = "osc"
+
+	Disable      PedalMode = "disable"
+	ReleaseAll   PedalMode = "releaseAll"
+	NextProfile  PedalMode = "nextProfile"
+	ReloadConfig PedalMode = "reloadConfig"
+	PauseFor     PedalMode = "pauseFor"
 )
 
 // Pedal behaviour
@@ -19,6 +50,38 @@ const (
 	Oneshot PedalBehaviour = "oneshot"
 	Toggle  PedalBehaviour = "toggle"
 	Hold    PedalBehaviour = "hold"
+	Leader  PedalBehaviour = "leader"
+
+	LayerMomentary PedalBehaviour = "layerMomentary"
+	LayerToggle    PedalBehaviour = "layerToggle"
+	LayerOneshot   PedalBehaviour = "layerOneshot"
+
+	Cycle        PedalBehaviour = "cycle"
+	PressRelease PedalBehaviour = "pressRelease"
+
+	StickyModifier PedalBehaviour = "stickyModifier"
+)
+
+// All pedal modes and behaviours, in the order they are listed in validation errors
+var (
+	modes      = []PedalMode{Sequence, Combo, Click, DoubleClick, Scroll, MouseMove, Command, OpenURL, LaunchApp, FocusWindow, HTTP, Text, Paste, Script, Plugin, Gamepad, Media, OBS, OSC, Disable, ReleaseAll, NextProfile, ReloadConfig, PauseFor}
+	behaviours = []PedalBehaviour{Oneshot, Toggle, Hold, Leader, LayerMomentary, LayerToggle, LayerOneshot, Cycle, PressRelease, StickyModifier}
+)
+
+// Default time allowed between presses of a leader sequence
+const DefaultSequenceTimeoutMs = 1000
+
+// Default time window for double pressing a sticky modifier pedal (lock)
+const DefaultDoublePressMs = 400
+
+// Default time after which a paste pedal restores the clipboard
+const DefaultClipboardRestoreMs = 500
+
+// Auto-repeat defaults and limits
+const (
+	DefaultRepeatDelayMs    = 500
+	DefaultRepeatIntervalMs = 50
+	MinRepeatIntervalMs     = 10
 )
 
 // PedalAction describes what a pedal does when pressed
@@ -26,60 +89,224 @@ type PedalAction struct {
 	// Mode defines how keys are triggered
 	// sequence: keys pressed one after another
 	// combo:    keys pressed together (a key combination)
+	// click:       mouse button click, held down by toggle and hold pedals
+	// doubleClick: mouse button double click
+	// scroll:      mouse wheel scroll
+	// mouseMove:   continuous mouse pointer movement while held (toggle and hold only)
+	// command:     run a program
+	// openUrl:     open a URL with the default browser (or handler)
+	// launchApp:   start an application
+	// focusWindow: bring a window to the front
+	// http:        send an HTTP request
+	// text:        type a text snippet
+	// paste:       paste a text snippet through the clipboard
+	// script:      run a Lua script
+	// plugin:      invoke an action of an external plugin
+	// gamepad:     virtual gamepad button or axis (Linux only)
+	// media:       control a media player through MPRIS (Linux only)
+	// obs:         control OBS Studio through obs-websocket
+	// osc:         send an OSC message over UDP
+	// disable:      disable StepKeys, pressing the pedal again while disabled enables it
+	// releaseAll:   release every held key and button and reset pedal states
+	// nextProfile:  switch to the next pedal map in the profiles directory
+	// reloadConfig: reload the pedal map and the app config from file
+	// pauseFor:     disable StepKeys for a duration
 	Mode PedalMode `json:"mode" example:"sequence"`
 
 	// Keys are the key names sent to the OS
 	// Supported: https://github.com/go-vgo/robotgo/blob/master/docs/keys.md#keys
 	Keys []string `json:"keys" example:"ctrl,shift,escape"`
 
+	// Button is the mouse button of the click modes
+	// Supported: left, right, middle
+	Button string `json:"button,omitempty" example:"left"`
+
+	// Direction is the direction of the scroll and mouseMove modes
+	// Supported: up, down, left, right
+	Direction string `json:"direction,omitempty" example:"down"`
+
+	// Steps is the number of scroll steps of the scroll mode, defaults to 1
+	Steps int `json:"steps,omitempty" example:"3"`
+
+	// Motion configures the speed of the mouseMove mode, defaults are used if omitted
+	Motion *MouseMotion `json:"motion,omitempty"`
+
+	// Command is the program run by the command mode
+	Command *CommandAction `json:"command,omitempty"`
+
+	// URL is opened by the openUrl mode
+	URL string `json:"url,omitempty" example:"https://github.com/BrNi05/StepKeys"`
+
+	// App is the application started by the launchApp mode
+	// An application name (macOS), a path or an executable name found using PATH
+	App string `json:"app,omitempty" example:"firefox"`
+
+	// AppArgs are passed to the application started by the launchApp mode
+	AppArgs []string `json:"appArgs,omitempty" example:"--new-window"`
+
+	// Window selects the window brought to the front by the focusWindow mode
+	Window *WindowMatch `json:"window,omitempty"`
+
+	// TargetWindow sends the keys of the sequence and combo modes to a window instead of the focused one (X11 only)
+	TargetWindow *TargetWindow `json:"targetWindow,omitempty"`
+
+	// HTTP is the request sent by the http mode
+	HTTP *HTTPRequest `json:"http,omitempty"`
+
+	// Text is the snippet typed by the text mode (or pasted by the paste mode), rendered on every press
+	// Placeholders: {{date}}, {{time}}, {{clipboard}}, {{counter "name"}} and {{env "NAME"}}
+	Text string `json:"text,omitempty" example:"[{{time}}] Speaker {{counter \"speaker\"}}: "`
+
+	// ClipboardRestoreMs is the time after which the paste mode restores the previous clipboard contents
+	// Defaults to DefaultClipboardRestoreMs
+	ClipboardRestoreMs int `json:"clipboardRestoreMs,omitempty" example:"500"`
+
+	// Script is the Lua script run by the script mode
+	Script *ScriptAction `json:"script,omitempty"`
+
+	// Plugin is the plugin action invoked by the plugin mode
+	Plugin *PluginAction `json:"plugin,omitempty"`
+
+	// GamepadButton is pressed by the gamepad mode
+	GamepadButton string `json:"gamepadButton,omitempty" example:"a"`
+
+	// GamepadAxis is moved to AxisValue by the gamepad mode while the pedal is held or latched
+	// Used instead of GamepadButton
+	GamepadAxis string `json:"gamepadAxis,omitempty" example:"rt"`
+
+	// AxisValue is the position of GamepadAxis, -1 to 1 for sticks and 0 to 1 for triggers
+	AxisValue float64 `json:"axisValue,omitempty" example:"1"`
+
+	// Media is the media player operation of the media mode
+	Media *MediaAction `json:"media,omitempty"`
+
+	// OBS is the OBS Studio operation of the obs mode
+	OBS *OBSAction `json:"obs,omitempty"`
+
+	// OSC describes the messages sent by the osc mode
+	OSC *OSCAction `json:"osc,omitempty"`
+
+	// Duration is the pause of the pauseFor mode, eg. 30s or 5m
+	Duration string `json:"duration,omitempty" example:"30s"`
+
 	// Behaviour defines how a pedal behaves while pressed
 	// oneshot: press keys once per pedal press
 	// toggle:  the keys are held down until the pedal is pressed again
 	// hold:    the keys are held down until the pedal is released
+	// leader:  the next pedal presses select one of the sequences
+	// layerMomentary: the layer is active while the pedal is held
+	// layerToggle:    the layer is active until the pedal is pressed again
+	// layerOneshot:   the layer is applied to the next pedal press only
+	// cycle:   each press triggers the next action of the list
+	// pressRelease: separate actions are triggered on press and on release
+	// stickyModifier: the modifier keys are applied to the next pedal action, a double press locks them
 	Behaviour PedalBehaviour `json:"behaviour" example:"oneshot"`
+
+	// Sequences are the ordered pedal presses a leader pedal waits for
+	// Only used by the leader behaviour
+	Sequences []LeaderSequence `json:"sequences,omitempty"`
+
+	// TimeoutMs is the time allowed between two presses of a leader sequence
+	// Only used by the leader behaviour, defaults to DefaultSequenceTimeoutMs
+	TimeoutMs int `json:"timeoutMs,omitempty" example:"1000"`
+
+	// Layer is the name of the layer a layer switch pedal activates
+	// Only used by the layer behaviours
+	Layer string `json:"layer,omitempty" example:"nav"`
+
+	// Actions are triggered one after another on each press, wrapping around
+	// Only used by the cycle behaviour, every action must be oneshot
+	Actions []PedalAction `json:"actions,omitempty"`
+
+	// CycleResetMs restarts the cycle from the first action if the pedal was not pressed for this long
+	// Only used by the cycle behaviour, 0 means the cycle is never reset automatically
+	CycleResetMs int `json:"cycleResetMs,omitempty" example:"5000"`
+
+	// OnPress is triggered when the pedal is pressed
+	// Only used by the pressRelease behaviour, must be oneshot
+	OnPress *PedalAction `json:"onPress,omitempty"`
+
+	// OnRelease is triggered when the pedal is released after a press
+	// Only used by the pressRelease behaviour, must be oneshot
+	OnRelease *PedalAction `json:"onRelease,omitempty"`
+
+	// CooldownMs is the minimum time between two actions of this pedal
+	// Presses during the cooldown are ignored (logged), 0 disables the cooldown
+	CooldownMs int `json:"cooldownMs,omitempty" example:"1000"`
+
+	// Group makes toggle pedals mutually exclusive (radio group)
+	// Latching a toggle pedal unlatches the other toggle pedals of the same group
+	// Only used by the toggle behaviour
+	Group string `json:"group,omitempty" example:"movement"`
+
+	// DoublePressMs is the time window in which a second press locks a sticky modifier
+	// Only used by the stickyModifier behaviour, defaults to DefaultDoublePressMs
+	DoublePressMs int `json:"doublePressMs,omitempty" example:"400"`
+
+	// AutoRepeat re-taps the keys while the pedal is held instead of holding them down
+	// Only used by the hold behaviour
+	AutoRepeat *AutoRepeat `json:"autoRepeat,omitempty"`
+
+	// Layers override the action of this pedal while the named layer is active
+	// Pedals without an override on the active layer fall through to this (base) action
+	Layers map[string]PedalAction `json:"layers,omitempty"`
 }
 
-// PedalMap represents the full pedal configuration
-// @Description Map of pedal IDs to their assigned actions
-type PedalMap map[string]PedalAction
+// Returns the clipboard restore time of the paste mode with the default applied
+func (a PedalAction) ClipboardRestore() time.Duration {
+	if a.ClipboardRestoreMs > 0 {
+		return time.Duration(a.ClipboardRestoreMs) * time.Millisecond
+	}
+	return DefaultClipboardRestoreMs * time.Millisecond
+}
+
+// LeaderSequence maps pedal presses following a leader pedal to an action
+type LeaderSequence struct {
+	// Pedals are the pedal IDs that have to be pressed in order after the leader pedal
+	Pedals []string `json:"pedals" example:"1,3"`
 
-// Validate the pedal mode string
-func isValidMode(mode PedalMode) bool {
-	return mode == Sequence || mode == Combo
+	// Action is triggered once the sequence is completed
+	// Its behaviour must be oneshot
+	Action PedalAction `json:"action"`
 }
 
-// Validate the pedal behaviour string
-func isValidBehaviour(behaviour PedalBehaviour) bool {
-	return behaviour == Oneshot || behaviour == Toggle || behaviour == Hold
+// AutoRepeat configures the timing of repeated key taps
+type AutoRepeat struct {
+	// DelayMs is the time between the first tap and the start of repeating
+	// Defaults to DefaultRepeatDelayMs
+	DelayMs int `json:"delayMs" example:"500"`
+
+	// IntervalMs is the time between two repeated taps
+	// Defaults to DefaultRepeatIntervalMs
+	IntervalMs int `json:"intervalMs" example:"50"`
 }
 
-// Checks if all keys are valid
-// Supported: https://github.com/go-vgo/robotgo/blob/master/docs/keys.md#keys
-func isValidKeys(keys []string) bool {
-	for _, key := range keys {
-		if _, ok := ValidKeys[key]; !ok {
-			return false
-		}
+// Returns the repeat delay with the default applied
+func (r AutoRepeat) Delay() time.Duration {
+	if r.DelayMs > 0 {
+		return time.Duration(r.DelayMs) * time.Millisecond
 	}
-
-	// All keys were found to be valid
-	return true
+	return DefaultRepeatDelayMs * time.Millisecond
 }
 
-func ValidatePedalMap(m PedalMap) error {
-	for pedalID, action := range m {
-		if !isValidMode(action.Mode) {
-			return fmt.Errorf("Pedal %q: invalid mode %q (use <sequence> or <combo>)",
-				pedalID, action.Mode)
-		}
-		if !isValidBehaviour(action.Behaviour) {
-			return fmt.Errorf("Pedal %q: invalid behaviour %q (use <oneshot>, <toggle> or <hold>)",
-				pedalID, action.Behaviour)
-		}
-
-		if !isValidKeys(action.Keys) {
-			return fmt.Errorf("Pedal %q: contains invalid keys", pedalID)
-		}
+// Returns the repeat interval with the default applied
+func (r AutoRepeat) Interval() time.Duration {
+	if r.IntervalMs > 0 {
+		return time.Duration(r.IntervalMs) * time.Millisecond
 	}
-	return nil
+	return DefaultRepeatIntervalMs * time.Millisecond
 }
+
+// Checks if the mode controls StepKeys itself
+func IsMetaMode(mode PedalMode) bool {
+	return mode == Disable || mode == ReleaseAll || mode == NextProfile || mode == ReloadConfig || mode == PauseFor
+}
+
+// Checks if the behaviour switches layers
+func IsLayerSwitch(behaviour PedalBehaviour) bool {
+	return behaviour == LayerMomentary || behaviour == LayerToggle || behaviour == LayerOneshot
+}
+
+// PedalMap represents the full pedal configuration
+// @Description Map of pedal IDs to their assigned actions
+type PedalMap map[string]PedalAction
@@ -0,0 +1,17 @@
+package pedal
+
+import (
+	"encoding/json"
+)
+
+// PluginAction describes an action run by an external plugin
+type PluginAction struct {
+	// Name is the name of the plugin in config.json
+	Name string `json:"name" example:"jira"`
+
+	// Action is passed to the plugin, which decides what to do
+	Action string `json:"action" example:"startTimer"`
+
+	// Params are passed to the plugin as is
+	Params json.RawMessage `json:"params,omitempty" swaggertype:"object"`
+}
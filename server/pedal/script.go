@@ -0,0 +1,42 @@
+package pedal
+
+import (
+	"time"
+)
+
+// Default time a script may run before it is stopped
+const DefaultScriptTimeoutMs = 5000
+
+// ScriptPermissions grant a script access beyond the StepKeys API
+type ScriptPermissions struct {
+	// FS allows the io and os libraries (files and the clock)
+	FS bool `json:"fs,omitempty" example:"false"`
+
+	// Net allows HTTP requests using sk.http
+	Net bool `json:"net,omitempty" example:"false"`
+}
+
+// ScriptAction describes a Lua script run by a script pedal
+type ScriptAction struct {
+	// Source is the inline Lua code
+	Source string `json:"source,omitempty" example:"sk.tap(\"ctrl\", \"s\")"`
+
+	// File is the path of a Lua file inside the scripts directory of StepKeys
+	// Only used if Source is empty
+	File string `json:"file,omitempty" example:"save.lua"`
+
+	// TimeoutMs is the time the script may run before it is stopped
+	// Defaults to DefaultScriptTimeoutMs
+	TimeoutMs int `json:"timeoutMs,omitempty" example:"5000"`
+
+	// Permissions are denied by default
+	Permissions ScriptPermissions `json:"permissions,omitempty"`
+}
+
+// Returns the timeout with the default applied
+func (s ScriptAction) Timeout() time.Duration {
+	if s.TimeoutMs > 0 {
+		return time.Duration(s.TimeoutMs) * time.Millisecond
+	}
+	return DefaultScriptTimeoutMs * time.Millisecond
+}
@@ -121,3 +121,21 @@ var ValidKeys = map[string]struct{}{
 	"K": {}, "L": {}, "M": {}, "N": {}, "O": {}, "P": {}, "Q": {}, "R": {}, "S": {}, "T": {},
 	"U": {}, "V": {}, "W": {}, "X": {}, "Y": {}, "Z": {},
 }
+
+// Modifier keys, a subset of ValidKeys
+// Used by sticky modifier pedals
+var ModifierKeys = map[string]struct{}{
+	"cmd":     {},
+	"lcmd":    {},
+	"rcmd":    {},
+	"alt":     {},
+	"lalt":    {},
+	"ralt":    {},
+	"ctrl":    {},
+	"lctrl":   {},
+	"rctrl":   {},
+	"control": {},
+	"shift":   {},
+	"lshift":  {},
+	"rshift":  {},
+}
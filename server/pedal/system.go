@@ -0,0 +1,25 @@
+package pedal
+
+// WindowMatch selects a window by its process or title
+// Both are case-insensitive substrings, if both are set the process is tried first
+type WindowMatch struct {
+	// Process is matched against the process names
+	Process string `json:"process,omitempty" example:"code"`
+
+	// Title is matched against the window titles
+	Title string `json:"title,omitempty" example:"Jira"`
+}
+
+// TargetWindow selects the X11 window the keys of a sequence or combo action are sent to
+// Both are case-insensitive substrings, if both are set both have to match
+type TargetWindow struct {
+	// Class is matched against the WM_CLASS instance and class names
+	Class string `json:"class,omitempty" example:"obs"`
+
+	// Title is matched against the window titles
+	Title string `json:"title,omitempty" example:"OBS"`
+
+	// Focus briefly focuses the window instead of sending synthetic key events
+	// Needed for applications that ignore synthetic events, the previous window gets the focus back
+	Focus bool `json:"focus,omitempty" example:"false"`
+}
@@ -0,0 +1,683 @@
+package pedal
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checks that need packages this package does not depend on
+// Set by the handler package, which implements text snippets and scripts, skipped if nil
+var (
+	ParseText     func(text string) error
+	CompileScript func(script ScriptAction) error
+)
+
+// Validate the pedal mode string
+func isValidMode(mode PedalMode) bool {
+	return slices.Contains(modes, mode)
+}
+
+// Validate the pedal behaviour string
+func isValidBehaviour(behaviour PedalBehaviour) bool {
+	return slices.Contains(behaviours, behaviour)
+}
+
+// Helper: format options for validation errors, eg. <a>, <b> or <c>
+func formatOptions[T ~string](options []T) string {
+	formatted := make([]string, len(options))
+	for i, option := range options {
+		formatted[i] = "<" + string(option) + ">"
+	}
+
+	last := len(formatted) - 1
+	if last < 1 {
+		return strings.Join(formatted, "")
+	}
+	return strings.Join(formatted[:last], ", ") + " or " + formatted[last]
+}
+
+// Checks if all keys are valid
+// Supported: https://github.com/go-vgo/robotgo/blob/master/docs/keys.md#keys
+func isValidKeys(keys []string) bool {
+	for _, key := range keys {
+		if _, ok := ValidKeys[key]; !ok {
+			return false
+		}
+	}
+
+	// All keys were found to be valid
+	return true
+}
+
+// Checks if a pedal ID is a decimal number in the range the protocol allows (0-127)
+func isValidPedalID(pedalID string) bool {
+	id, err := strconv.Atoi(pedalID)
+	return err == nil && id >= 0 && id <= 127 && strconv.Itoa(id) == pedalID
+}
+
+// Checks if toggle and hold pedals can hold the mode down
+func isHoldableMode(mode PedalMode) bool {
+	return mode == Sequence || mode == Combo || mode == Click || mode == MouseMove || mode == Gamepad || mode == Media
+}
+
+// URL schemes openUrl pedals may use without being privileged
+// Other schemes (eg. file: or custom handlers) can start local programs
+var webURLSchemes = []string{"http", "https", "mailto"}
+
+// Checks if the action can run programs or reach files and the network
+// Such actions are only accepted from the config files, not through the API
+func IsPrivileged(action PedalAction) bool {
+	switch action.Mode {
+	case Command, LaunchApp:
+		return true
+	case OpenURL:
+		u, err := url.Parse(action.URL)
+		return err != nil || !slices.Contains(webURLSchemes, u.Scheme)
+	case Script:
+		return action.Script != nil && (action.Script.Permissions.FS || action.Script.Permissions.Net)
+	}
+	return false
+}
+
+// Returns the privileged actions of a pedal map, nested actions included
+func PrivilegedActions(pedalMap PedalMap) []PedalAction {
+	var found []PedalAction
+	walkActions(pedalMap, func(action PedalAction) {
+		if IsPrivileged(action) {
+			found = append(found, action)
+		}
+	})
+	return found
+}
+
+// Helper: visit every action of a pedal map, nested actions included
+func walkActions(pedalMap PedalMap, visit func(action PedalAction)) {
+	var walk func(action PedalAction)
+	walk = func(action PedalAction) {
+		visit(action)
+		for _, sequence := range action.Sequences {
+			walk(sequence.Action)
+		}
+		for _, nested := range action.Actions {
+			walk(nested)
+		}
+		if action.OnPress != nil {
+			walk(*action.OnPress)
+		}
+		if action.OnRelease != nil {
+			walk(*action.OnRelease)
+		}
+		for _, layer := range action.Layers {
+			walk(layer)
+		}
+	}
+
+	for _, action := range pedalMap {
+		walk(action)
+	}
+}
+
+// Validate the mode specific fields of an action
+func validateMode(pedalID string, action PedalAction) error {
+	if !isValidMode(action.Mode) {
+		return fmt.Errorf("Pedal %q: invalid mode %q (use %s)",
+			pedalID, action.Mode, formatOptions(modes))
+	}
+
+	switch action.Mode {
+	case Sequence, Combo:
+		if !isValidKeys(action.Keys) {
+			return fmt.Errorf("Pedal %q: contains invalid keys", pedalID)
+		}
+
+	case Click, DoubleClick:
+		if !slices.Contains(MouseButtons, action.Button) {
+			return fmt.Errorf("Pedal %q: invalid mouse button %q (use %s)",
+				pedalID, action.Button, formatOptions(MouseButtons))
+		}
+
+	case Scroll:
+		if !slices.Contains(Directions, action.Direction) {
+			return fmt.Errorf("Pedal %q: invalid scroll direction %q (use %s)",
+				pedalID, action.Direction, formatOptions(Directions))
+		}
+		if action.Steps < 0 {
+			return fmt.Errorf("Pedal %q: invalid scroll steps %d", pedalID, action.Steps)
+		}
+
+	case MouseMove:
+		if !slices.Contains(Directions, action.Direction) {
+			return fmt.Errorf("Pedal %q: invalid movement direction %q (use %s)",
+				pedalID, action.Direction, formatOptions(Directions))
+		}
+		if m := action.Motion; m != nil && (m.Speed < 0 || m.Acceleration < 0 || m.MaxSpeed < 0) {
+			return fmt.Errorf("Pedal %q: motion values cannot be negative", pedalID)
+		}
+
+		// Movement only happens while the pedal is held or latched
+		if (action.Behaviour != Toggle && action.Behaviour != Hold) || action.AutoRepeat != nil {
+			return fmt.Errorf("Pedal %q: mode %q requires <toggle> or <hold> behaviour without auto-repeat", pedalID, action.Mode)
+		}
+
+	case Command:
+		if err := validateCommand(pedalID, action.Command); err != nil {
+			return err
+		}
+
+	case OpenURL:
+		if u, err := url.Parse(action.URL); err != nil || u.Scheme == "" {
+			return fmt.Errorf("Pedal %q: invalid URL %q", pedalID, action.URL)
+		}
+
+	case LaunchApp:
+		if action.App == "" {
+			return fmt.Errorf("Pedal %q: no application to launch", pedalID)
+		}
+
+	case FocusWindow:
+		if action.Window == nil || (action.Window.Process == "" && action.Window.Title == "") {
+			return fmt.Errorf("Pedal %q: no window process or title to focus", pedalID)
+		}
+
+	case HTTP:
+		if err := validateHTTP(pedalID, action.HTTP); err != nil {
+			return err
+		}
+
+	case Text, Paste:
+		if action.Text == "" {
+			return fmt.Errorf("Pedal %q: no text to type", pedalID)
+		}
+		if ParseText != nil {
+			if err := ParseText(action.Text); err != nil {
+				return fmt.Errorf("Pedal %q: invalid text template: %v", pedalID, err)
+			}
+		}
+		if action.ClipboardRestoreMs < 0 {
+			return fmt.Errorf("Pedal %q: invalid clipboard restore time %d", pedalID, action.ClipboardRestoreMs)
+		}
+
+	case Script:
+		if action.Script == nil || (action.Script.Source == "" && action.Script.File == "") {
+			return fmt.Errorf("Pedal %q: script pedal has no source or file", pedalID)
+		}
+		if action.Script.TimeoutMs < 0 {
+			return fmt.Errorf("Pedal %q: invalid script timeout %d", pedalID, action.Script.TimeoutMs)
+		}
+		if CompileScript != nil {
+			if err := CompileScript(*action.Script); err != nil {
+				return fmt.Errorf("Pedal %q: script error: %v", pedalID, err)
+			}
+		}
+
+	case Plugin:
+		if action.Plugin == nil || action.Plugin.Name == "" || action.Plugin.Action == "" {
+			return fmt.Errorf("Pedal %q: plugin pedal needs a plugin name and an action", pedalID)
+		}
+
+	case Gamepad:
+		if err := validateGamepad(pedalID, action); err != nil {
+			return err
+		}
+
+	case Media:
+		if err := validateMedia(pedalID, action); err != nil {
+			return err
+		}
+
+	case OBS:
+		if err := validateOBS(pedalID, action.OBS); err != nil {
+			return err
+		}
+
+	case OSC:
+		if err := validateOSC(pedalID, action.OSC); err != nil {
+			return err
+		}
+
+	case PauseFor:
+		if d, err := time.ParseDuration(action.Duration); err != nil || d <= 0 {
+			return fmt.Errorf("Pedal %q: invalid pause duration %q (eg. 30s or 5m)", pedalID, action.Duration)
+		}
+	}
+
+	// Meta actions control StepKeys itself, repeating them makes no sense
+	if IsMetaMode(action.Mode) && action.Behaviour != Oneshot {
+		return fmt.Errorf("Pedal %q: mode %q requires <oneshot> behaviour", pedalID, action.Mode)
+	}
+
+	// Repeated actions are tapped, so only holding the mode down needs support
+	holds := action.Behaviour == Toggle || (action.Behaviour == Hold && action.AutoRepeat == nil)
+	if holds && !isHoldableMode(action.Mode) {
+		return fmt.Errorf("Pedal %q: mode %q cannot be held down (use <oneshot> or auto-repeat)", pedalID, action.Mode)
+	}
+
+	// Release messages are only sent by oneshot pedals
+	if sendsOnRelease(action) && action.Behaviour != Oneshot {
+		return fmt.Errorf("Pedal %q: onRelease requires <oneshot> behaviour", pedalID)
+	}
+
+	// Keys sent to a window are only tapped, they cannot be held down there
+	if action.TargetWindow != nil {
+		if action.Mode != Sequence && action.Mode != Combo {
+			return fmt.Errorf("Pedal %q: target window requires mode <sequence> or <combo>", pedalID)
+		}
+		if holds {
+			return fmt.Errorf("Pedal %q: keys sent to a target window cannot be held down (use <oneshot> or auto-repeat)", pedalID)
+		}
+		if action.TargetWindow.Class == "" && action.TargetWindow.Title == "" {
+			return fmt.Errorf("Pedal %q: no target window class or title", pedalID)
+		}
+	}
+
+	return nil
+}
+
+// Validate the program of a command pedal
+func validateCommand(pedalID string, command *CommandAction) error {
+	if command == nil || command.Program == "" {
+		return fmt.Errorf("Pedal %q: command has no program", pedalID)
+	}
+	if command.Shell && len(command.Args) > 0 {
+		return fmt.Errorf("Pedal %q: shell commands take the arguments in the program (command line)", pedalID)
+	}
+	if command.TimeoutMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid command timeout %d", pedalID, command.TimeoutMs)
+	}
+	if command.Policy != "" && !slices.Contains(policies, command.Policy) {
+		return fmt.Errorf("Pedal %q: invalid command policy %q (use %s)",
+			pedalID, command.Policy, formatOptions(policies))
+	}
+
+	return nil
+}
+
+// Validate the request of an http pedal
+func validateHTTP(pedalID string, request *HTTPRequest) error {
+	if request == nil {
+		return fmt.Errorf("Pedal %q: http pedal has no request", pedalID)
+	}
+	if u, err := url.Parse(request.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Pedal %q: invalid HTTP URL %q", pedalID, request.URL)
+	}
+	if request.Method != "" && !slices.Contains(httpMethods, request.Method) {
+		return fmt.Errorf("Pedal %q: invalid HTTP method %q (use %s)",
+			pedalID, request.Method, formatOptions(httpMethods))
+	}
+	if request.TimeoutMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid HTTP timeout %d", pedalID, request.TimeoutMs)
+	}
+
+	// Render with sample data, so template and JSON errors are not found at press time
+	if _, err := request.RenderBody("press", time.Now()); err != nil {
+		return fmt.Errorf("Pedal %q: invalid HTTP body: %v", pedalID, err)
+	}
+
+	return nil
+}
+
+// Checks if the action sends a message on pedal release as well
+// Only pedals get release events, nested actions (cycle, press/release and leader sequence actions) do not
+func sendsOnRelease(action PedalAction) bool {
+	switch action.Mode {
+	case HTTP:
+		return action.HTTP != nil && action.HTTP.OnRelease
+	case OSC:
+		return action.OSC != nil && action.OSC.OnRelease
+	default:
+		return false
+	}
+}
+
+// Validate the button or axis of a gamepad pedal
+func validateGamepad(pedalID string, action PedalAction) error {
+	if action.GamepadAxis == "" {
+		if !slices.Contains(ValidGamepadButtons, action.GamepadButton) {
+			return fmt.Errorf("Pedal %q: invalid gamepad button %q (use %s)",
+				pedalID, action.GamepadButton, formatOptions(ValidGamepadButtons))
+		}
+		return nil
+	}
+
+	if action.GamepadButton != "" {
+		return fmt.Errorf("Pedal %q: gamepad pedals use a button or an axis, not both", pedalID)
+	}
+	if !slices.Contains(ValidGamepadAxes, action.GamepadAxis) {
+		return fmt.Errorf("Pedal %q: invalid gamepad axis %q (use %s)",
+			pedalID, action.GamepadAxis, formatOptions(ValidGamepadAxes))
+	}
+
+	low := -1.0
+	if IsGamepadTrigger(action.GamepadAxis) {
+		low = 0
+	}
+	if action.AxisValue < low || action.AxisValue > 1 {
+		return fmt.Errorf("Pedal %q: axis value %g is out of range (%g to 1)", pedalID, action.AxisValue, low)
+	}
+
+	// The axis returns to rest on release, so it has to be held
+	if (action.Behaviour != Toggle && action.Behaviour != Hold) || action.AutoRepeat != nil {
+		return fmt.Errorf("Pedal %q: gamepad axes require <toggle> or <hold> behaviour without auto-repeat", pedalID)
+	}
+
+	return nil
+}
+
+// Validate the auto-repeat settings of a pedal
+func validateAutoRepeat(pedalID string, action PedalAction) error {
+	if action.AutoRepeat == nil {
+		return nil
+	}
+
+	if action.Behaviour != Hold {
+		return fmt.Errorf("Pedal %q: auto-repeat requires <hold> behaviour", pedalID)
+	}
+	if action.AutoRepeat.DelayMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid auto-repeat delay %d", pedalID, action.AutoRepeat.DelayMs)
+	}
+	if action.AutoRepeat.IntervalMs != 0 && action.AutoRepeat.IntervalMs < MinRepeatIntervalMs {
+		return fmt.Errorf("Pedal %q: auto-repeat interval must be at least %dms", pedalID, MinRepeatIntervalMs)
+	}
+
+	return nil
+}
+
+// Validate the actions of a cycle pedal
+func validateCycle(pedalID string, action PedalAction) error {
+	if len(action.Actions) == 0 {
+		return fmt.Errorf("Pedal %q: cycle pedal has no actions", pedalID)
+	}
+	if action.CycleResetMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid cycle reset time %d", pedalID, action.CycleResetMs)
+	}
+
+	for i, cycleAction := range action.Actions {
+		if cycleAction.Behaviour != Oneshot {
+			return fmt.Errorf("Pedal %q: cycle action %d must use <oneshot> behaviour", pedalID, i)
+		}
+		if sendsOnRelease(cycleAction) {
+			return fmt.Errorf("Pedal %q: cycle action %d cannot use onRelease", pedalID, i)
+		}
+		if err := validateMode(pedalID, cycleAction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate the press and release actions of a pressRelease pedal
+func validatePressRelease(pedalID string, action PedalAction) error {
+	if action.OnPress == nil && action.OnRelease == nil {
+		return fmt.Errorf("Pedal %q: press/release pedal has no actions", pedalID)
+	}
+
+	events := []struct {
+		name   string
+		action *PedalAction
+	}{{"press", action.OnPress}, {"release", action.OnRelease}}
+
+	for _, event := range events {
+		eventAction := event.action
+		if eventAction == nil {
+			continue
+		}
+		if eventAction.Behaviour != Oneshot {
+			return fmt.Errorf("Pedal %q: %s action must use <oneshot> behaviour", pedalID, event.name)
+		}
+		if sendsOnRelease(*eventAction) {
+			return fmt.Errorf("Pedal %q: %s action cannot use onRelease (use the onRelease action)", pedalID, event.name)
+		}
+		if err := validateMode(pedalID, *eventAction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate the keys of a sticky modifier pedal
+func validateStickyModifier(pedalID string, action PedalAction) error {
+	if len(action.Keys) == 0 {
+		return fmt.Errorf("Pedal %q: sticky modifier pedal has no keys", pedalID)
+	}
+	for _, key := range action.Keys {
+		if _, ok := ModifierKeys[key]; !ok {
+			return fmt.Errorf("Pedal %q: %q is not a modifier key", pedalID, key)
+		}
+	}
+	if action.DoublePressMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid double press time %d", pedalID, action.DoublePressMs)
+	}
+
+	return nil
+}
+
+// Validate the sequences of a leader pedal
+func validateSequences(m PedalMap, pedalID string, action PedalAction) error {
+	if len(action.Sequences) == 0 {
+		return fmt.Errorf("Pedal %q: leader pedal has no sequences", pedalID)
+	}
+	if action.TimeoutMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid timeout %d", pedalID, action.TimeoutMs)
+	}
+
+	seen := make(map[string]bool)
+	for _, seq := range action.Sequences {
+		if len(seq.Pedals) == 0 {
+			return fmt.Errorf("Pedal %q: empty leader sequence", pedalID)
+		}
+		for _, id := range seq.Pedals {
+			if !isValidPedalID(id) {
+				return fmt.Errorf("Pedal %q: invalid pedal ID %q in sequence", pedalID, id)
+			}
+
+			// Pressing a leader pedal restarts the sequence, so it can never be a step
+			if m[id].Behaviour == Leader {
+				return fmt.Errorf("Pedal %q: leader pedal %q cannot be part of a sequence", pedalID, id)
+			}
+		}
+
+		joined := strings.Join(seq.Pedals, ",")
+		if seen[joined] {
+			return fmt.Errorf("Pedal %q: duplicate sequence %q", pedalID, joined)
+		}
+		seen[joined] = true
+
+		if seq.Action.Behaviour != Oneshot {
+			return fmt.Errorf("Pedal %q: sequence %q must use <oneshot> behaviour", pedalID, joined)
+		}
+		if sendsOnRelease(seq.Action) {
+			return fmt.Errorf("Pedal %q: sequence %q cannot use onRelease", pedalID, joined)
+		}
+		if err := validateMode(pedalID, seq.Action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Collect the layer names that have at least one pedal override
+func definedLayers(m PedalMap) map[string]bool {
+	layers := make(map[string]bool)
+	for _, action := range m {
+		for name := range action.Layers {
+			layers[name] = true
+		}
+	}
+	return layers
+}
+
+// Validate a single pedal action
+// Layer overrides are validated as well, but cannot have layers of their own
+func validateAction(m PedalMap, layers map[string]bool, pedalID string, action PedalAction, onLayer bool) error {
+	if !isValidBehaviour(action.Behaviour) {
+		return fmt.Errorf("Pedal %q: invalid behaviour %q (use %s)",
+			pedalID, action.Behaviour, formatOptions(behaviours))
+	}
+
+	if len(action.Layers) > 0 {
+		if onLayer {
+			return fmt.Errorf("Pedal %q: layer overrides cannot have layers", pedalID)
+		}
+		if IsLayerSwitch(action.Behaviour) {
+			return fmt.Errorf("Pedal %q: layer switch pedals cannot have layer overrides", pedalID)
+		}
+
+		for name, layerAction := range action.Layers {
+			if name == "" {
+				return fmt.Errorf("Pedal %q: empty layer name", pedalID)
+			}
+			if err := validateAction(m, layers, pedalID, layerAction, true); err != nil {
+				return fmt.Errorf("%w (layer %q)", err, name)
+			}
+		}
+	}
+
+	if err := validateAutoRepeat(pedalID, action); err != nil {
+		return err
+	}
+
+	if action.CooldownMs < 0 {
+		return fmt.Errorf("Pedal %q: invalid cooldown %d", pedalID, action.CooldownMs)
+	}
+
+	if action.Group != "" && action.Behaviour != Toggle {
+		return fmt.Errorf("Pedal %q: groups require <toggle> behaviour", pedalID)
+	}
+
+	switch {
+	// Leader pedals do not send keys themselves
+	case action.Behaviour == Leader:
+		return validateSequences(m, pedalID, action)
+
+	// Cycle pedals trigger the keys of their actions
+	case action.Behaviour == Cycle:
+		return validateCycle(pedalID, action)
+
+	// Press/release pedals trigger the keys of their event actions
+	case action.Behaviour == PressRelease:
+		return validatePressRelease(pedalID, action)
+
+	// Sticky modifier pedals only hold modifier keys
+	case action.Behaviour == StickyModifier:
+		return validateStickyModifier(pedalID, action)
+
+	// Layer switch pedals only need an existing layer
+	case IsLayerSwitch(action.Behaviour):
+		if !layers[action.Layer] {
+			return fmt.Errorf("Pedal %q: unknown layer %q (no pedal defines it)", pedalID, action.Layer)
+		}
+		return nil
+
+	default:
+		return validateMode(pedalID, action)
+	}
+}
+
+func ValidatePedalMap(m PedalMap) error {
+	layers := definedLayers(m)
+
+	for pedalID, action := range m {
+		if err := validateAction(m, layers, pedalID, action, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate the operation of a media pedal
+func validateMedia(pedalID string, action PedalAction) error {
+	media := action.Media
+	if media == nil {
+		return fmt.Errorf("Pedal %q: media pedal has no operation", pedalID)
+	}
+	if !slices.Contains(MediaOperations, media.Operation) {
+		return fmt.Errorf("Pedal %q: invalid media operation %q (use %s)",
+			pedalID, media.Operation, formatOptions(MediaOperations))
+	}
+
+	switch media.Operation {
+	case MediaSeek:
+		if media.Seconds == 0 {
+			return fmt.Errorf("Pedal %q: media seek needs a non-zero offset in seconds", pedalID)
+		}
+	case MediaRate:
+		if media.Rate <= 0 {
+			return fmt.Errorf("Pedal %q: invalid media rate %g", pedalID, media.Rate)
+		}
+	}
+
+	if media.RewindOnResume < 0 {
+		return fmt.Errorf("Pedal %q: invalid media rewind %g (use a positive number of seconds)", pedalID, media.RewindOnResume)
+	}
+
+	// Holding plays the player until the pedal is released (or pressed again)
+	holds := action.Behaviour == Toggle || (action.Behaviour == Hold && action.AutoRepeat == nil)
+	if holds && media.Operation != MediaPlay {
+		return fmt.Errorf("Pedal %q: only the media operation %q can be held down", pedalID, MediaPlay)
+	}
+
+	return nil
+}
+
+// Validate the operation of an obs pedal
+func validateOBS(pedalID string, obs *OBSAction) error {
+	if obs == nil {
+		return fmt.Errorf("Pedal %q: obs pedal has no operation", pedalID)
+	}
+
+	switch obs.Operation {
+	case OBSScene:
+		if obs.Scene == "" {
+			return fmt.Errorf("Pedal %q: no OBS scene to switch to", pedalID)
+		}
+	case OBSToggleSource:
+		if obs.Source == "" {
+			return fmt.Errorf("Pedal %q: no OBS source to toggle", pedalID)
+		}
+	case OBSToggleMute:
+		if obs.Input == "" {
+			return fmt.Errorf("Pedal %q: no OBS input to mute", pedalID)
+		}
+	case OBSHotkey:
+		if obs.Hotkey == "" {
+			return fmt.Errorf("Pedal %q: no OBS hotkey name", pedalID)
+		}
+	default:
+		if !slices.Contains(OBSOperations, obs.Operation) {
+			return fmt.Errorf("Pedal %q: invalid OBS operation %q (use %s)",
+				pedalID, obs.Operation, formatOptions(OBSOperations))
+		}
+	}
+
+	return nil
+}
+
+// Validate the messages of an osc pedal
+func validateOSC(pedalID string, osc *OSCAction) error {
+	if osc == nil {
+		return fmt.Errorf("Pedal %q: osc pedal has no message", pedalID)
+	}
+	if _, _, err := net.SplitHostPort(osc.Target); err != nil {
+		return fmt.Errorf("Pedal %q: invalid OSC target %q (use host:port)", pedalID, osc.Target)
+	}
+
+	if _, _, err := osc.PressMessage(); err != nil {
+		return fmt.Errorf("Pedal %q: invalid OSC message: %v", pedalID, err)
+	}
+
+	if osc.OnRelease {
+		if _, _, err := osc.ReleaseMessage(); err != nil {
+			return fmt.Errorf("Pedal %q: invalid OSC release message: %v", pedalID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package pedal
+
+import (
+	"testing"
+)
+
+func TestIsPrivileged(t *testing.T) {
+	actions := []struct {
+		name       string
+		action     PedalAction
+		privileged bool
+	}{
+		{"keys", PedalAction{Mode: Sequence, Keys: []string{"a"}}, false},
+		{"command", PedalAction{Mode: Command, Command: &CommandAction{Program: "ls"}}, true},
+		{"launchApp", PedalAction{Mode: LaunchApp, App: "code"}, true},
+		{"https URL", PedalAction{Mode: OpenURL, URL: "https://example.com/"}, false},
+		{"http URL", PedalAction{Mode: OpenURL, URL: "HTTP://example.com/"}, false},
+		{"mailto URL", PedalAction{Mode: OpenURL, URL: "mailto:someone@example.com"}, false},
+		{"file URL", PedalAction{Mode: OpenURL, URL: "file:///tmp/script.sh"}, true},
+		{"custom scheme", PedalAction{Mode: OpenURL, URL: "vscode://file/tmp/x"}, true},
+		{"relative URL", PedalAction{Mode: OpenURL, URL: "/usr/bin/xterm"}, true},
+		{"broken URL", PedalAction{Mode: OpenURL, URL: "http://[::1"}, true},
+		{"script", PedalAction{Mode: Script, Script: &ScriptAction{Source: "x = 1"}}, false},
+		{"script with fs", PedalAction{Mode: Script, Script: &ScriptAction{Source: "x = 1", Permissions: ScriptPermissions{FS: true}}}, true},
+		{"script with net", PedalAction{Mode: Script, Script: &ScriptAction{Source: "x = 1", Permissions: ScriptPermissions{Net: true}}}, true},
+	}
+	for _, a := range actions {
+		if got := IsPrivileged(a.action); got != a.privileged {
+			t.Errorf("%s: got %t, want %t", a.name, got, a.privileged)
+		}
+	}
+}
+
+func TestPrivilegedActions(t *testing.T) {
+	fileURL := PedalAction{Mode: OpenURL, URL: "file:///tmp/script.sh", Behaviour: Oneshot}
+	webURL := PedalAction{Mode: OpenURL, URL: "https://example.com/", Behaviour: Oneshot}
+
+	m := PedalMap{
+		"1": webURL,
+		"2": {Behaviour: Cycle, Actions: []PedalAction{webURL, fileURL}},
+		"3": {Behaviour: PressRelease, OnRelease: &fileURL},
+		"4": {Mode: Sequence, Keys: []string{"a"}, Behaviour: Oneshot, Layers: map[string]PedalAction{"nav": fileURL}},
+		"5": {Behaviour: Leader, Sequences: []LeaderSequence{{Pedals: []string{"1"}, Action: fileURL}}},
+	}
+	if found := PrivilegedActions(m); len(found) != 4 {
+		t.Errorf("got %d privileged actions, want 4: %+v", len(found), found)
+	}
+}
+
+func TestOnReleaseValidation(t *testing.T) {
+	release := PedalAction{Mode: HTTP, Behaviour: Oneshot, HTTP: &HTTPRequest{URL: "http://localhost:8123/hook", OnRelease: true}}
+	oscRelease := PedalAction{Mode: OSC, Behaviour: Oneshot, OSC: &OSCAction{Target: "127.0.0.1:8000", Address: "/play", OnRelease: true}}
+	press := release
+	press.HTTP = &HTTPRequest{URL: "http://localhost:8123/hook"}
+	repeated := release
+	repeated.Behaviour = Hold
+	repeated.AutoRepeat = &AutoRepeat{}
+
+	tests := []struct {
+		name   string
+		action PedalAction
+		valid  bool
+	}{
+		{"oneshot", release, true},
+		{"layer", PedalAction{Mode: Sequence, Keys: []string{"a"}, Behaviour: Oneshot, Layers: map[string]PedalAction{"nav": release}}, true},
+		{"auto-repeat", repeated, false},
+		{"cycle", PedalAction{Behaviour: Cycle, Actions: []PedalAction{press, release}}, false},
+		{"cycle without onRelease", PedalAction{Behaviour: Cycle, Actions: []PedalAction{press, press}}, true},
+		{"press action", PedalAction{Behaviour: PressRelease, OnPress: &release}, false},
+		{"release action", PedalAction{Behaviour: PressRelease, OnRelease: &release}, false},
+		{"leader sequence", PedalAction{Behaviour: Leader, Sequences: []LeaderSequence{{Pedals: []string{"2"}, Action: release}}}, false},
+		{"osc oneshot", oscRelease, true},
+		{"osc cycle", PedalAction{Behaviour: Cycle, Actions: []PedalAction{oscRelease}}, false},
+		{"osc release action", PedalAction{Behaviour: PressRelease, OnRelease: &oscRelease}, false},
+		{"osc leader sequence", PedalAction{Behaviour: Leader, Sequences: []LeaderSequence{{Pedals: []string{"2"}, Action: oscRelease}}}, false},
+	}
+
+	for _, test := range tests {
+		err := ValidatePedalMap(PedalMap{"1": test.action, "2": press})
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%s: got error %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+}
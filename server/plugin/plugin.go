@@ -0,0 +1,259 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	Log "stepkeys/server/logging"
+	OS "stepkeys/server/os"
+)
+
+// Restart backoff of crashed plugins
+// The backoff is reset once a plugin ran for stableAfter, vars so tests can shorten them
+var (
+	minBackoff  = time.Second
+	maxBackoff  = time.Minute
+	stableAfter = time.Minute
+)
+
+// Messages waiting to be written to a plugin, further messages are dropped
+const outQueueSize = 64
+
+// Time the stderr output of an exited plugin is read for
+const stderrDrainTimeout = time.Second
+
+// Config describes a plugin executable in config.json
+type Config struct {
+	// Name is used by plugin pedals to select the plugin
+	Name string `json:"name"`
+
+	// Command is the executable to run (resolved using PATH)
+	Command string `json:"command"`
+
+	// Args are passed to the executable
+	Args []string `json:"args,omitempty"`
+
+	// Dir is the working directory, defaults to the StepKeys directory
+	Dir string `json:"dir,omitempty"`
+}
+
+// A supervised plugin process
+type plugin struct {
+	config Config
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	out     chan []byte // nil while the process is not running
+	cmd     *exec.Cmd
+	nextID  int
+	pending map[string]*invocation // invoke requests waiting for a response: request ID -> invocation
+}
+
+// Running plugins: name -> plugin
+var (
+	plugins   = make(map[string]*plugin)
+	pluginsMu sync.Mutex
+)
+
+// Start and supervise the plugins
+// Plugins with an empty or duplicate name are skipped
+func Start(configs []Config) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for _, config := range configs {
+		if config.Name == "" || config.Command == "" {
+			Log.WriteToLogFile("Plugin skipped: name and command are required.")
+			continue
+		}
+		if _, ok := plugins[config.Name]; ok {
+			Log.WriteToLogFile(fmt.Sprintf("Plugin %q skipped: duplicate name.", config.Name))
+			continue
+		}
+
+		p := &plugin{config: config, stop: make(chan struct{})}
+		plugins[config.Name] = p
+		go p.supervise()
+	}
+}
+
+// Stop every plugin
+// Called on shutdown
+func StopAll() {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for name, p := range plugins {
+		close(p.stop)
+
+		p.mu.Lock()
+		if p.cmd != nil && p.cmd.Process != nil {
+			p.cmd.Process.Kill()
+		}
+		p.mu.Unlock()
+
+		delete(plugins, name)
+	}
+}
+
+// Run the plugin and restart it with backoff until it is stopped
+func (p *plugin) supervise() {
+	backoff := minBackoff
+	for {
+		start := time.Now()
+		err := p.run()
+
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		if time.Since(start) >= stableAfter {
+			backoff = minBackoff
+		}
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q exited (%v), restarting in %s.", p.config.Name, err, backoff))
+
+		select {
+		case <-time.After(backoff):
+		case <-p.stop:
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// Start the process and serve it until it exits
+func (p *plugin) run() error {
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = OS.GetExeDir()
+	}
+
+	out := make(chan []byte, outQueueSize)
+	stdin, stdout, stderr, err := p.start(cmd, out)
+	if err != nil || stdin == nil {
+		return err
+	}
+
+	Log.WriteToLogFile(fmt.Sprintf("Plugin %q started.", p.config.Name))
+
+	stderrDone := make(chan struct{})
+	go writeLines(stdin, out)
+	go func() {
+		p.logStderr(stderr)
+		close(stderrDone)
+	}()
+	p.serve(stdout)
+
+	// cmd.Wait closes the pipes, so the last stderr lines (eg. of a crash) are read first
+	// Child processes may keep stderr open, they are not waited for long
+	select {
+	case <-stderrDone:
+	case <-time.After(stderrDrainTimeout):
+	}
+
+	p.mu.Lock()
+	p.out = nil
+	p.cmd = nil
+	close(out)
+	p.mu.Unlock()
+
+	return cmd.Wait()
+}
+
+// Helper: create the pipes and start the process, unless StopAll already ran (nil pipes, no error)
+// The pipes are closed on every error path, cmd.Wait closes them once the process was started
+func (p *plugin) start(cmd *exec.Cmd, out chan []byte) (stdin io.WriteCloser, stdout, stderr io.ReadCloser, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Do not start the process if StopAll already ran, it would never be killed
+	select {
+	case <-p.stop:
+		return nil, nil, nil, nil
+	default:
+	}
+
+	var pipes []io.Closer
+	defer func() {
+		if err != nil {
+			for _, pipe := range pipes {
+				pipe.Close()
+			}
+		}
+	}()
+
+	if stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, nil, nil, err
+	}
+	pipes = append(pipes, stdin)
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, nil, nil, err
+	}
+	pipes = append(pipes, stdout)
+	if stderr, err = cmd.StderrPipe(); err != nil {
+		return nil, nil, nil, err
+	}
+	pipes = append(pipes, stderr)
+
+	if err = cmd.Start(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	p.out = out
+	p.cmd = cmd
+	return stdin, stdout, stderr, nil
+}
+
+// Helper: write queued messages to the plugin until the queue is closed
+func writeLines(w io.WriteCloser, out <-chan []byte) {
+	defer w.Close()
+	for line := range out {
+		if _, err := w.Write(line); err != nil {
+			// Keep draining, the process is exiting
+			continue
+		}
+	}
+}
+
+// Helper: write the stderr lines of the plugin to the log
+func (p *plugin) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q: %s", p.config.Name, scanner.Text()))
+	}
+}
+
+// Queue a message for the plugin without blocking
+// Returns false if the message was dropped
+func (p *plugin) send(msg message) bool {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q: failed to encode message: %v", p.config.Name, err))
+		return false
+	}
+	data = append(data, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.out == nil {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q is not running, message dropped.", p.config.Name))
+		return false
+	}
+	select {
+	case p.out <- data:
+		return true
+	default:
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q is not reading, message dropped.", p.config.Name))
+		return false
+	}
+}
@@ -0,0 +1,360 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	Log "stepkeys/server/logging"
+)
+
+// Helper: number of open file descriptors of the test process
+func openFiles(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count open files: ", err)
+	}
+	return len(entries)
+}
+
+// Helper: the log lines written since from, see logMark
+func logsSince(from int) []string {
+	return slices.Clone(Log.ReadCurrentSessionLogs()[from:])
+}
+
+// Helper: the position of the next log line
+func logMark() int {
+	return len(Log.ReadCurrentSessionLogs())
+}
+
+// Helper: wait until a log line since from contains the text
+func waitForLog(t *testing.T, from int, text string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, line := range logsSince(from) {
+			if strings.Contains(line, text) {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no log line contains %q, got:\n%s", text, strings.Join(logsSince(from), "\n"))
+}
+
+// Helper: a plugin that is not backed by a process, its messages are queued on out
+func newTestPlugin(t *testing.T, name string) *plugin {
+	t.Helper()
+	p := &plugin{config: Config{Name: name}, stop: make(chan struct{}), out: make(chan []byte, outQueueSize)}
+
+	pluginsMu.Lock()
+	plugins[name] = p
+	pluginsMu.Unlock()
+	t.Cleanup(func() {
+		pluginsMu.Lock()
+		delete(plugins, name)
+		pluginsMu.Unlock()
+	})
+	return p
+}
+
+// Helper: the next message the plugin would receive
+func nextMessage(t *testing.T, p *plugin) message {
+	t.Helper()
+	select {
+	case data := <-p.out:
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("message %q: %v", data, err)
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message sent to the plugin")
+		return message{}
+	}
+}
+
+func TestServe(t *testing.T) {
+	var tapped []string
+	var typed string
+	enabled := false
+	SetCallbacks(Callbacks{
+		TapKeys: func(keys []string) error {
+			if keys[0] == "nope" {
+				return fmt.Errorf("invalid key")
+			}
+			tapped = append(tapped, keys...)
+			return nil
+		},
+		TypeText:   func(text string) error { typed = text; return nil },
+		IsEnabled:  func() bool { return enabled },
+		SetEnabled: func(state bool) { enabled = state },
+	})
+	t.Cleanup(func() { SetCallbacks(Callbacks{}) })
+
+	requests := []struct {
+		request string
+		result  string // JSON result, empty if an error is expected
+		code    int
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"tap","params":{"keys":["ctrl","s"]}}`, `true`, 0},
+		{`{"jsonrpc":"2.0","id":2,"method":"type","params":{"text":"hello"}}`, `true`, 0},
+		{`{"jsonrpc":"2.0","id":"three","method":"setEnabled","params":{"enabled":true}}`, `true`, 0},
+		{`{"jsonrpc":"2.0","id":4,"method":"getEnabled"}`, `true`, 0},
+		{`{"jsonrpc":"2.0","id":5,"method":"log","params":{"message":"from the plugin"}}`, `true`, 0},
+		{`{"jsonrpc":"2.0","id":6,"method":"tap","params":{"keys":[]}}`, ``, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":7,"method":"tap","params":{"keys":["nope"]}}`, ``, codeInternalError},
+		{`{"jsonrpc":"2.0","id":8,"method":"setEnabled","params":{}}`, ``, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":9,"method":"type","params":"text"}`, ``, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":10,"method":"reboot"}`, ``, codeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":11,"method":`, ``, codeParseError},
+	}
+
+	p := newTestPlugin(t, "serve")
+	mark := logMark()
+	for _, r := range requests {
+		p.serve(strings.NewReader(r.request + "\n"))
+		msg := nextMessage(t, p)
+
+		var request struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if r.code != codeParseError {
+			json.Unmarshal([]byte(r.request), &request)
+			if string(msg.ID) != string(request.ID) {
+				t.Errorf("%s: got response ID %s", r.request, msg.ID)
+			}
+		} else if string(msg.ID) != "null" {
+			t.Errorf("%s: got response ID %s, want null", r.request, msg.ID)
+		}
+
+		switch {
+		case r.code != 0 && (msg.Error == nil || msg.Error.Code != r.code):
+			t.Errorf("%s: got %+v, want error code %d", r.request, msg, r.code)
+		case r.code == 0 && (msg.Error != nil || string(msg.Result) != r.result):
+			t.Errorf("%s: got result %s and error %+v, want %s", r.request, msg.Result, msg.Error, r.result)
+		}
+		if msg.JSONRPC != "2.0" {
+			t.Errorf("%s: got jsonrpc %q", r.request, msg.JSONRPC)
+		}
+	}
+
+	if strings.Join(tapped, ",") != "ctrl,s" || typed != "hello" || !enabled {
+		t.Errorf("callbacks got keys %v, text %q, enabled %t", tapped, typed, enabled)
+	}
+	waitForLog(t, mark, `Plugin "serve": from the plugin`)
+
+	// Notifications get no response
+	p.serve(strings.NewReader(`{"jsonrpc":"2.0","method":"setEnabled","params":{"enabled":false}}` + "\n"))
+	select {
+	case data := <-p.out:
+		t.Errorf("notification answered with %s", data)
+	default:
+	}
+	if enabled {
+		t.Error("notification was not handled")
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	saved := invokeTimeout
+	invokeTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { invokeTimeout = saved })
+
+	p := newTestPlugin(t, "invoke")
+	mark := logMark()
+
+	// Request IDs match responses to their invoke requests
+	Invoke("invoke", "ok", json.RawMessage(`{"issue":"SK-42"}`))
+	Invoke("invoke", "fail", nil)
+	ok, fail := nextMessage(t, p), nextMessage(t, p)
+	if ok.Method != "invoke" || string(ok.ID) == string(fail.ID) {
+		t.Fatalf("got requests %+v and %+v", ok, fail)
+	}
+	var params struct {
+		Action string          `json:"action"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(ok.Params, &params); err != nil || params.Action != "ok" || string(params.Params) != `{"issue":"SK-42"}` {
+		t.Errorf("invoke params: got %s", ok.Params)
+	}
+
+	p.serve(strings.NewReader(fmt.Sprintf(
+		`{"jsonrpc":"2.0","id":%s,"error":{"code":1,"message":"no such issue"}}`+"\n"+
+			`{"jsonrpc":"2.0","id":%s,"result":true}`+"\n"+
+			`{"jsonrpc":"2.0","id":%s,"result":true}`+"\n", fail.ID, ok.ID, ok.ID)))
+	waitForLog(t, mark, `Plugin "invoke" returned an error for invoke "fail": no such issue`)
+	waitForLog(t, mark, fmt.Sprintf(`Plugin "invoke" sent a response to an unknown request (ID %s).`, ok.ID))
+
+	// Requests without a response time out
+	Invoke("invoke", "slow", nil)
+	nextMessage(t, p)
+	waitForLog(t, mark, `Plugin "invoke" did not answer invoke "slow" within 50ms.`)
+
+	time.Sleep(2 * invokeTimeout)
+	for _, line := range logsSince(mark) {
+		if strings.Contains(line, `invoke "ok" within`) || strings.Contains(line, `invoke "fail" within`) {
+			t.Errorf("answered request timed out: %s", line)
+		}
+	}
+
+	// Dropped requests are not waited for
+	p.mu.Lock()
+	p.out = nil
+	p.mu.Unlock()
+	Invoke("invoke", "dropped", nil)
+	p.mu.Lock()
+	pending := len(p.pending)
+	p.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("%d requests still pending", pending)
+	}
+
+	Invoke("missing", "ok", nil)
+	waitForLog(t, mark, `Plugin "missing" is not configured.`)
+}
+
+// Environment variable selecting the behaviour of the helper plugin process
+const helperEnv = "STEPKEYS_TEST_PLUGIN"
+
+// Not a test: the plugin process started by the process tests
+func TestHelperPlugin(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+
+	switch mode {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "fatal: something broke")
+		fmt.Fprintln(os.Stderr, "last words")
+		os.Exit(3)
+
+	case "actions":
+		fmt.Println(`{"jsonrpc":"2.0","method":"log","params":{"message":"ready"}}`)
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			var msg struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+				Params struct {
+					Action string `json:"action"`
+				} `json:"params"`
+			}
+			json.Unmarshal(scanner.Bytes(), &msg)
+			if msg.Method != "invoke" {
+				continue
+			}
+			switch msg.Params.Action {
+			case "ok":
+				fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":true}`+"\n", msg.ID)
+			case "fail":
+				fmt.Printf(`{"jsonrpc":"2.0","id":%s,"error":{"code":1,"message":"action failed"}}`+"\n", msg.ID)
+			}
+			fmt.Fprintln(os.Stderr, "handled "+msg.Params.Action)
+		}
+	}
+	os.Exit(0)
+}
+
+// Helper: start the test binary as a plugin, stopped at the end of the test
+func startHelperPlugin(t *testing.T, name, mode string) {
+	t.Helper()
+	t.Setenv(helperEnv, mode)
+	Start([]Config{{Name: name, Command: os.Args[0], Args: []string{"-test.run=^TestHelperPlugin$"}, Dir: t.TempDir()}})
+	t.Cleanup(StopAll)
+}
+
+func TestPluginProcess(t *testing.T) {
+	mark := logMark()
+	startHelperPlugin(t, "actions", "actions")
+
+	waitForLog(t, mark, `Plugin "actions": ready`)
+	Invoke("actions", "ok", nil)
+	Invoke("actions", "fail", nil)
+	waitForLog(t, mark, `Plugin "actions" returned an error for invoke "fail": action failed`)
+	waitForLog(t, mark, `Plugin "actions": handled ok`)
+
+	for _, line := range logsSince(mark) {
+		if strings.Contains(line, "unknown request") || strings.Contains(line, `invoke "ok" failed`) {
+			t.Errorf("unexpected log line: %s", line)
+		}
+	}
+}
+
+func TestRestartWithBackoff(t *testing.T) {
+	savedMin, savedMax := minBackoff, maxBackoff
+	minBackoff, maxBackoff = 20*time.Millisecond, 80*time.Millisecond
+	t.Cleanup(func() { minBackoff, maxBackoff = savedMin, savedMax })
+
+	mark := logMark()
+	startHelperPlugin(t, "crasher", "crash")
+
+	// The backoff doubles up to the maximum
+	waitForLog(t, mark, `Plugin "crasher" exited (exit status 3), restarting in 20ms.`)
+	waitForLog(t, mark, `restarting in 40ms.`)
+	waitForLog(t, mark, `restarting in 80ms.`)
+	StopAll()
+
+	// The stderr output of every crash is logged before the exit
+	lines := logsSince(mark)
+	exits := 0
+	for i, line := range lines {
+		if !strings.Contains(line, `Plugin "crasher" exited`) {
+			continue
+		}
+		exits++
+		if i < 2 || !strings.Contains(lines[i-1], "last words") || !strings.Contains(lines[i-2], "fatal: something broke") {
+			t.Errorf("exit %d not preceded by the stderr output:\n%s", exits, strings.Join(lines[:i+1], "\n"))
+		}
+	}
+	if exits < 3 {
+		t.Errorf("got %d restarts", exits)
+	}
+}
+
+func TestRunClosesPipesWhenStartFails(t *testing.T) {
+	p := &plugin{
+		config: Config{Name: "missing", Command: "stepkeys-missing-plugin", Dir: t.TempDir()},
+		stop:   make(chan struct{}),
+	}
+
+	before := openFiles(t)
+	for range 10 {
+		if err := p.run(); err == nil {
+			t.Fatal("no error for a missing executable")
+		}
+	}
+	if after := openFiles(t); after > before {
+		t.Errorf("%d files leaked", after-before)
+	}
+	if p.out != nil || p.cmd != nil {
+		t.Error("failed start left the plugin marked as running")
+	}
+}
+
+func TestRunAfterStop(t *testing.T) {
+	p := &plugin{
+		config: Config{Name: "stopped", Command: "true", Dir: t.TempDir()},
+		stop:   make(chan struct{}),
+	}
+	close(p.stop)
+
+	before := openFiles(t)
+	if err := p.run(); err != nil {
+		t.Fatal(err)
+	}
+	if after := openFiles(t); after > before {
+		t.Errorf("%d files leaked", after-before)
+	}
+	if p.cmd != nil {
+		t.Error("process started after stop")
+	}
+}
@@ -0,0 +1,228 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	Log "stepkeys/server/logging"
+)
+
+// Maximum size of a message sent by a plugin
+const maxMessageSize = 1024 * 1024
+
+// Time a plugin has to answer an invoke request, a var so tests can shorten it
+var invokeTimeout = 10 * time.Second
+
+// An invoke request waiting for its response
+type invocation struct {
+	action string
+	timer  *time.Timer // logs the missing response
+}
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// A JSON-RPC 2.0 request, notification or response, one per line
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Functions plugins can call
+// Set by the config package, so this package does not depend on the handler and config packages
+type Callbacks struct {
+	TapKeys    func(keys []string) error
+	TypeText   func(text string) error
+	IsEnabled  func() bool
+	SetEnabled func(enabled bool)
+}
+
+var callbacks Callbacks
+
+// Register the functions plugins can call
+func SetCallbacks(c Callbacks) {
+	callbacks = c
+}
+
+// Send a pedal event notification to every plugin
+// Never blocks, so it can be called from the pedal handler
+func PedalEvent(pedalID int, pressed bool) {
+	params, _ := json.Marshal(map[string]any{"pedal": pedalID, "pressed": pressed})
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for _, p := range plugins {
+		p.send(message{Method: "pedal", Params: params})
+	}
+}
+
+// Ask a plugin to run one of its actions
+// Errors returned by the plugin are written to the log
+func Invoke(name string, action string, actionParams json.RawMessage) {
+	params, _ := json.Marshal(map[string]any{"action": action, "params": actionParams})
+
+	pluginsMu.Lock()
+	p, ok := plugins[name]
+	pluginsMu.Unlock()
+
+	if !ok {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q is not configured.", name))
+		return
+	}
+
+	p.mu.Lock()
+	p.nextID++
+	id, _ := json.Marshal(p.nextID)
+	key := string(id)
+	if p.pending == nil {
+		p.pending = make(map[string]*invocation)
+	}
+	p.pending[key] = &invocation{
+		action: action,
+		timer: time.AfterFunc(invokeTimeout, func() {
+			if p.takePending(key) != nil {
+				Log.WriteToLogFile(fmt.Sprintf("Plugin %q did not answer invoke %q within %s.", name, action, invokeTimeout))
+			}
+		}),
+	}
+	p.mu.Unlock()
+
+	if !p.send(message{ID: id, Method: "invoke", Params: params}) {
+		if inv := p.takePending(key); inv != nil {
+			inv.timer.Stop()
+		}
+	}
+}
+
+// Helper: remove and return the invoke request waiting for the response with the ID, nil if there is none
+func (p *plugin) takePending(key string) *invocation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inv := p.pending[key]
+	delete(p.pending, key)
+	return inv
+}
+
+// Handle the response to an invoke request, matched by its ID
+func (p *plugin) handleResponse(msg message) {
+	inv := p.takePending(string(msg.ID))
+	if inv == nil {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q sent a response to an unknown request (ID %s).", p.config.Name, msg.ID))
+		return
+	}
+	inv.timer.Stop()
+
+	if msg.Error != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q returned an error for invoke %q: %s", p.config.Name, inv.action, msg.Error.Message))
+	}
+}
+
+// Read and handle the messages of the plugin until its stdout is closed
+func (p *plugin) serve(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			p.send(message{ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			continue
+		}
+
+		switch {
+		// Response to an invoke call
+		case msg.Method == "":
+			p.handleResponse(msg)
+
+		// Request or notification (no ID, no response)
+		default:
+			result, rpcErr := p.call(msg.Method, msg.Params)
+			if msg.ID == nil {
+				continue
+			}
+			response := message{ID: msg.ID, Error: rpcErr}
+			if rpcErr == nil {
+				response.Result, _ = json.Marshal(result)
+			}
+			p.send(response)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q: %v", p.config.Name, err))
+	}
+}
+
+// Run a method called by a plugin
+func (p *plugin) call(method string, params json.RawMessage) (any, *rpcError) {
+	switch method {
+	case "tap":
+		var args struct {
+			Keys []string `json:"keys"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil || len(args.Keys) == 0 {
+			return nil, &rpcError{codeInvalidParams, "keys expected"}
+		}
+		if err := callbacks.TapKeys(args.Keys); err != nil {
+			return nil, &rpcError{codeInternalError, err.Error()}
+		}
+		return true, nil
+
+	case "type":
+		var args struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &rpcError{codeInvalidParams, "text expected"}
+		}
+		if err := callbacks.TypeText(args.Text); err != nil {
+			return nil, &rpcError{codeInternalError, err.Error()}
+		}
+		return true, nil
+
+	case "getEnabled":
+		return callbacks.IsEnabled(), nil
+
+	case "setEnabled":
+		var args struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil || args.Enabled == nil {
+			return nil, &rpcError{codeInvalidParams, "enabled expected"}
+		}
+		callbacks.SetEnabled(*args.Enabled)
+		return callbacks.IsEnabled(), nil
+
+	case "log":
+		var args struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &rpcError{codeInvalidParams, "message expected"}
+		}
+		Log.WriteToLogFile(fmt.Sprintf("Plugin %q: %s", p.config.Name, args.Message))
+		return true, nil
+
+	default:
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("unknown method %q", method)}
+	}
+}
@@ -0,0 +1,64 @@
+package snippet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	Log "stepkeys/server/logging"
+	OS "stepkeys/server/os"
+)
+
+// Persistent counters: name -> last value
+// Loaded on first use, saved after every rendered snippet that used them
+var (
+	counters       map[string]int
+	countersMu     sync.Mutex
+	countersLoaded bool
+)
+
+// Returns the StepKeys directory, a var so tests can use a temp directory
+var exeDir = OS.GetExeDir
+
+// Helper: path of the counter file next to the executable
+func countersFilePath() string {
+	return filepath.Join(exeDir(), "counters.json")
+}
+
+// Increment a counter in values and return its new value, the first value is 1
+func incrementCounter(values map[string]int, name string) (int, error) {
+	if name == "" {
+		return 0, errors.New("empty counter name")
+	}
+
+	values[name]++
+	return values[name], nil
+}
+
+// Helper: read the counter file once, a missing or broken file starts from zero
+func loadCounters() {
+	if countersLoaded {
+		return
+	}
+	countersLoaded = true
+	counters = make(map[string]int)
+
+	data, err := os.ReadFile(countersFilePath())
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		Log.WriteToLogFile("Error parsing counters, starting from zero: " + err.Error())
+		counters = make(map[string]int)
+	}
+}
+
+// Helper: write the counters to file
+func saveCounters() {
+	data, _ := json.MarshalIndent(counters, "", "  ")
+	if err := os.WriteFile(countersFilePath(), data, 0644); err != nil {
+		Log.WriteToLogFile("Failed to save counters: " + err.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package snippet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Helper: use a temp directory as the StepKeys directory, counters are loaded from it again
+func useTempExeDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	saved := exeDir
+	exeDir = func() string { return dir }
+	countersLoaded = false
+	t.Cleanup(func() {
+		exeDir = saved
+		countersLoaded = false
+	})
+	return dir
+}
+
+// Helper: the counters saved in the counter file
+func savedCounters(t *testing.T, dir string) map[string]int {
+	t.Helper()
+
+	saved := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(dir, "counters.json"))
+	if os.IsNotExist(err) {
+		return saved
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+func TestCounter(t *testing.T) {
+	dir := useTempExeDir(t)
+
+	for _, want := range []string{"Take 1", "Take 2", "Take 3"} {
+		if got, err := Render(`Take {{counter "take"}}`); err != nil || got != want {
+			t.Errorf("got %q, %v, want %q", got, err, want)
+		}
+	}
+
+	// A counter used twice is incremented twice, other counters are independent
+	if got, _ := Render(`{{counter "take"}}-{{counter "take"}} {{counter "scene"}}`); got != "4-5 1" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := Render(`{{counter ""}}`); err == nil {
+		t.Error("no error for an empty counter name")
+	}
+
+	if saved := savedCounters(t, dir); saved["take"] != 5 || saved["scene"] != 1 || len(saved) != 2 {
+		t.Errorf("saved counters: %v", saved)
+	}
+
+	// Counters continue from the file
+	countersLoaded = false
+	if got, _ := Render(`{{counter "take"}}`); got != "6" {
+		t.Errorf("after reload: got %q", got)
+	}
+}
+
+func TestCounterNotSavedOnError(t *testing.T) {
+	dir := useTempExeDir(t)
+	useClipboard(t, nil)
+
+	if _, err := Render(`{{counter "take"}} {{clipboard}}`); err == nil {
+		t.Fatal("no error without a clipboard")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "counters.json")); !os.IsNotExist(err) {
+		t.Errorf("counters saved after a failed render: %v", savedCounters(t, dir))
+	}
+
+	if got, _ := Render(`{{counter "take"}}`); got != "1" {
+		t.Errorf("failed render incremented the counter: got %q", got)
+	}
+}
+
+func TestCounterBrokenFile(t *testing.T) {
+	dir := useTempExeDir(t)
+	os.WriteFile(filepath.Join(dir, "counters.json"), []byte("{broken"), 0644)
+
+	if got, err := Render(`{{counter "take"}}`); err != nil || got != "1" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if saved := savedCounters(t, dir); saved["take"] != 1 {
+		t.Errorf("saved counters: %v", saved)
+	}
+}
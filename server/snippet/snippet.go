@@ -0,0 +1,120 @@
+package snippet
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Snippets are Go text/template strings rendered when a pedal is pressed
+// Placeholders: {{date}}, {{time}}, {{clipboard}}, {{counter "name"}} and {{env "NAME"}}
+
+// Default layouts of the date and time placeholders
+const (
+	DefaultDateLayout = "2006-01-02"
+	DefaultTimeLayout = "15:04:05"
+)
+
+// Reads the clipboard for the clipboard placeholder
+// Set by the handler, so this package does not depend on the OS input library
+var ReadClipboard func() (string, error)
+
+// Maximum number of parsed snippets kept, the cache is cleared once it is full
+const maxParsedSnippets = 256
+
+// Parsed snippets: text -> template, so presses do not parse the text again
+var (
+	parsed   = make(map[string]*template.Template)
+	parsedMu sync.Mutex
+)
+
+// Placeholder functions
+// Parsing only needs the names, the functions are called while rendering
+var funcs = template.FuncMap{
+	"date": func(layout ...string) string {
+		return formatNow(DefaultDateLayout, layout)
+	},
+	"time": func(layout ...string) string {
+		return formatNow(DefaultTimeLayout, layout)
+	},
+	"clipboard": func() (string, error) {
+		if ReadClipboard == nil {
+			return "", errors.New("clipboard is not available")
+		}
+		return ReadClipboard()
+	},
+	// Replaced by Render, so counters are only saved once the whole snippet is rendered
+	"counter": func(name string) (int, error) {
+		return 0, errors.New("counter used outside of Render")
+	},
+	"env": os.Getenv,
+}
+
+// Helper: format the current time with an optional layout
+func formatNow(defaultLayout string, layout []string) string {
+	if len(layout) > 0 {
+		return time.Now().Format(layout[0])
+	}
+	return time.Now().Format(defaultLayout)
+}
+
+// Parse a snippet, used by validation so syntax errors are not found at press time
+func Parse(text string) (*template.Template, error) {
+	return template.New("snippet").Funcs(funcs).Parse(text)
+}
+
+// Render a snippet, counters used by it are incremented
+// Nothing is saved if rendering fails, so a failed press does not skip counter values
+func Render(text string) (string, error) {
+	tmpl, err := parseCached(text)
+	if err != nil {
+		return "", err
+	}
+
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	// Counters are incremented on a copy
+	loadCounters()
+	next := maps.Clone(counters)
+	tmpl = template.Must(tmpl.Clone()).Funcs(template.FuncMap{
+		"counter": func(name string) (int, error) {
+			return incrementCounter(next, name)
+		},
+	})
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return "", err
+	}
+
+	if !maps.Equal(next, counters) {
+		counters = next
+		saveCounters()
+	}
+	return out.String(), nil
+}
+
+// Helper: parse a snippet once and reuse the template on later presses
+func parseCached(text string) (*template.Template, error) {
+	parsedMu.Lock()
+	defer parsedMu.Unlock()
+
+	if tmpl, ok := parsed[text]; ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) >= maxParsedSnippets {
+		clear(parsed) // texts of edited pedals are not used anymore
+	}
+	parsed[text] = tmpl
+	return tmpl, nil
+}
@@ -0,0 +1,102 @@
+package snippet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper: replace the clipboard reader for the test
+func useClipboard(t *testing.T, read func() (string, error)) {
+	t.Helper()
+	saved := ReadClipboard
+	ReadClipboard = read
+	t.Cleanup(func() { ReadClipboard = saved })
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text  string
+		valid bool
+	}{
+		{"plain text", true},
+		{"", true},
+		{"{{date}} {{time}}", true},
+		{`{{date "02.01.2006"}} {{time "15:04"}}`, true},
+		{`{{clipboard}} {{counter "take"}} {{env "HOME"}}`, true},
+		{"{{date", false},
+		{"{{ end }}", false},
+		{"{{if}}", false},
+		{"{{unknown}}", false},
+		{`{{counter "a"`, false},
+		{"{{clipboard}", false},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.text)
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("Parse(%q): got error %v, want valid %t", test.text, err, test.valid)
+		}
+	}
+}
+
+func TestRenderDateTime(t *testing.T) {
+	tests := []struct {
+		text   string
+		layout string
+	}{
+		{"{{date}}", DefaultDateLayout},
+		{"{{time}}", DefaultTimeLayout},
+		{`{{date "02.01.2006"}}`, "02.01.2006"},
+		{`{{time "15:04"}}`, "15:04"},
+		{`{{date "Monday, 2 January"}}`, "Monday, 2 January"},
+	}
+
+	for _, test := range tests {
+		before := time.Now()
+		got, err := Render(test.text)
+		after := time.Now()
+		if err != nil {
+			t.Errorf("Render(%q): %v", test.text, err)
+			continue
+		}
+
+		// The clock may tick between the calls
+		if got != before.Format(test.layout) && got != after.Format(test.layout) {
+			t.Errorf("Render(%q): got %q, want layout %q", test.text, got, test.layout)
+		}
+	}
+}
+
+func TestRenderEnv(t *testing.T) {
+	t.Setenv("STEPKEYS_TEST_NAME", "Ada")
+
+	got, err := Render(`Hi {{env "STEPKEYS_TEST_NAME"}}{{env "STEPKEYS_TEST_UNSET"}}!`)
+	if err != nil || got != "Hi Ada!" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func TestRenderClipboard(t *testing.T) {
+	useClipboard(t, func() (string, error) { return "copied", nil })
+	if got, err := Render("> {{clipboard}}"); err != nil || got != "> copied" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	useClipboard(t, func() (string, error) { return "", errors.New("no display") })
+	if _, err := Render("{{clipboard}}"); err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("got error %v", err)
+	}
+
+	useClipboard(t, nil)
+	if _, err := Render("{{clipboard}}"); err == nil || !strings.Contains(err.Error(), "clipboard is not available") {
+		t.Errorf("nil ReadClipboard: got error %v", err)
+	}
+}
+
+func TestRenderParseError(t *testing.T) {
+	if _, err := Render("{{date"); err == nil {
+		t.Error("no error for a syntax error")
+	}
+}
@@ -9,6 +9,8 @@ import (
 
 	Config "stepkeys/server/config"
 	Log "stepkeys/server/logging"
+	OBSClient "stepkeys/server/obs"
+	PluginHost "stepkeys/server/plugin"
 	Updater "stepkeys/server/updater"
 )
 
@@ -128,6 +130,10 @@ func TrayOnReady() {
 func TrayOnExit() {
 	Log.WriteToLogFile("Tray menu is exiting.")
 	Log.WriteToLogFile("StepKeys is shutting down.")
+
+	// Plugin processes would outlive StepKeys
+	PluginHost.StopAll()
+	OBSClient.Stop()
 }
 
 // Browser open helper
@@ -0,0 +1,175 @@
+package uinput
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Path of the uinput device node
+const devicePath = "/dev/uinput"
+
+// uinput ioctl requests (linux/uinput.h)
+const (
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	uiDevSetup   = 0x405c5503 // _IOW('U', 3, struct uinput_setup)
+	uiAbsSetup   = 0x401c5504 // _IOW('U', 4, struct uinput_abs_setup)
+	uiSetEvBit   = 0x40045564
+	uiSetKeyBit  = 0x40045565
+	uiSetRelBit  = 0x40045566
+	uiSetAbsBit  = 0x40045567
+)
+
+// Bus type reported for the virtual devices
+const busVirtual = 0x06
+
+// struct uinput_setup
+type uinputSetup struct {
+	BusType      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	Name         [80]byte
+	FFEffectsMax uint32
+}
+
+// struct uinput_abs_setup
+type uinputAbsSetup struct {
+	Code       uint16
+	_          uint16
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// struct input_event
+type inputEvent struct {
+	Time  unix.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// Device is a virtual input device
+// Safe for concurrent use
+type Device struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+// Create a virtual input device with the given capabilities
+// Writing /dev/uinput usually needs a udev rule or membership of the input group
+func Create(name string, caps Capabilities) (*Device, error) {
+	file, err := os.OpenFile(devicePath, os.O_WRONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, fmt.Errorf("%s is not writable, allow it with a udev rule "+
+				"(KERNEL==\"uinput\", GROUP=\"input\", MODE=\"0660\") and add the user to the input group", devicePath)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s does not exist, load the uinput kernel module (modprobe uinput)", devicePath)
+		}
+		return nil, err
+	}
+
+	d := &Device{file: file}
+	if err := d.setup(name, caps); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create uinput device: %w", err)
+	}
+
+	return d, nil
+}
+
+// Register the capabilities and create the device
+func (d *Device) setup(name string, caps Capabilities) error {
+	fd := int(d.file.Fd())
+
+	if len(caps.Keys) > 0 {
+		if err := unix.IoctlSetInt(fd, uiSetEvBit, int(EvKey)); err != nil {
+			return err
+		}
+		for _, code := range caps.Keys {
+			if err := unix.IoctlSetInt(fd, uiSetKeyBit, int(code)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(caps.Rels) > 0 {
+		if err := unix.IoctlSetInt(fd, uiSetEvBit, int(EvRel)); err != nil {
+			return err
+		}
+		for _, code := range caps.Rels {
+			if err := unix.IoctlSetInt(fd, uiSetRelBit, int(code)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(caps.Abs) > 0 {
+		if err := unix.IoctlSetInt(fd, uiSetEvBit, int(EvAbs)); err != nil {
+			return err
+		}
+		for _, axis := range caps.Abs {
+			if err := unix.IoctlSetInt(fd, uiSetAbsBit, int(axis.Code)); err != nil {
+				return err
+			}
+			absSetup := uinputAbsSetup{Code: axis.Code, Minimum: axis.Min, Maximum: axis.Max}
+			if err := ioctlPtr(fd, uiAbsSetup, unsafe.Pointer(&absSetup)); err != nil {
+				return err
+			}
+		}
+	}
+
+	setup := uinputSetup{BusType: busVirtual, Vendor: caps.Vendor, Product: caps.Product, Version: 1}
+	if setup.Vendor == 0 {
+		setup.Vendor, setup.Product = 0x1209, 0x5350
+	}
+	copy(setup.Name[:len(setup.Name)-1], name)
+	if err := ioctlPtr(fd, uiDevSetup, unsafe.Pointer(&setup)); err != nil {
+		return err
+	}
+
+	return ioctlPtr(fd, uiDevCreate, nil)
+}
+
+// Helper: ioctl with a pointer argument
+func ioctlPtr(fd int, req uint, arg unsafe.Pointer) error {
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), uintptr(req), uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Emit events followed by a single sync report, so they take effect together
+func (d *Device) Emit(events ...Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ev := range append(events, Event{EvSyn, SynReport, 0}) {
+		raw := inputEvent{Type: ev.Type, Code: ev.Code, Value: ev.Value}
+		if err := binary.Write(d.file, binary.NativeEndian, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Destroy the device
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	unix.IoctlSetInt(int(d.file.Fd()), uiDevDestroy, 0)
+	return d.file.Close()
+}
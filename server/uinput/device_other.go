@@ -0,0 +1,19 @@
+//go:build !linux
+
+package uinput
+
+// Device is a virtual input device (Linux only)
+type Device struct{}
+
+// Create always fails outside of Linux
+func Create(name string, caps Capabilities) (*Device, error) {
+	return nil, ErrUnsupported
+}
+
+func (d *Device) Emit(events ...Event) error {
+	return ErrUnsupported
+}
+
+func (d *Device) Close() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+package uinput
+
+// Gamepad buttons (see pedal.ValidGamepadButtons) -> evdev button code
+var GamepadButtons = map[string]uint16{
+	"a":          0x130, // BTN_SOUTH
+	"b":          0x131, // BTN_EAST
+	"x":          0x133, // BTN_NORTH
+	"y":          0x134, // BTN_WEST
+	"lb":         0x136, // BTN_TL
+	"rb":         0x137, // BTN_TR
+	"back":       0x13a, // BTN_SELECT
+	"start":      0x13b, // BTN_START
+	"guide":      0x13c, // BTN_MODE
+	"lstick":     0x13d, // BTN_THUMBL
+	"rstick":     0x13e, // BTN_THUMBR
+	"dpad_up":    0x220, // BTN_DPAD_UP
+	"dpad_down":  0x221,
+	"dpad_left":  0x222,
+	"dpad_right": 0x223,
+}
+
+// Gamepad axes (see pedal.ValidGamepadAxes) -> evdev absolute axis with its range
+var GamepadAxes = map[string]AbsAxis{
+	"left_x":  {Code: 0x00, Min: -32768, Max: 32767}, // ABS_X
+	"left_y":  {Code: 0x01, Min: -32768, Max: 32767}, // ABS_Y
+	"right_x": {Code: 0x03, Min: -32768, Max: 32767}, // ABS_RX
+	"right_y": {Code: 0x04, Min: -32768, Max: 32767}, // ABS_RY
+	"lt":      {Code: 0x02, Min: 0, Max: 255},        // ABS_Z
+	"rt":      {Code: 0x05, Min: 0, Max: 255},        // ABS_RZ
+}
+
+// Capabilities of the virtual gamepad
+// Reports the USB IDs of an Xbox 360 controller, which games support out of the box
+func GamepadCapabilities() Capabilities {
+	caps := Capabilities{Vendor: 0x045e, Product: 0x028e}
+	for _, code := range GamepadButtons {
+		caps.Keys = append(caps.Keys, code)
+	}
+	for _, axis := range GamepadAxes {
+		caps.Abs = append(caps.Abs, axis)
+	}
+	return caps
+}
+
+// Convert an axis position (-1 to 1, or 0 to 1 for triggers) to the raw axis value
+func (a AbsAxis) Value(position float64) int32 {
+	if a.Min < 0 {
+		if position < 0 {
+			return int32(position * -float64(a.Min))
+		}
+		return int32(position * float64(a.Max))
+	}
+	return a.Min + int32(position*float64(a.Max-a.Min))
+}
@@ -0,0 +1,158 @@
+package uinput
+
+// Key is an evdev key code, optionally typed with shift held down
+type Key struct {
+	Code  uint16
+	Shift bool
+}
+
+// Evdev key codes (linux/input-event-codes.h)
+const (
+	KeyLeftShift uint16 = 42
+	KeyEnter     uint16 = 28
+	KeyTab       uint16 = 15
+	KeySpace     uint16 = 57
+)
+
+// StepKeys key names (see pedal.ValidKeys) -> evdev key
+var Keys = map[string]Key{
+	// Editing / navigation
+	"backspace": {Code: 14},
+	"delete":    {Code: 111},
+	"enter":     {Code: KeyEnter},
+	"tab":       {Code: KeyTab},
+	"esc":       {Code: 1},
+	"escape":    {Code: 1},
+	"up":        {Code: 103},
+	"down":      {Code: 108},
+	"left":      {Code: 105},
+	"right":     {Code: 106},
+	"home":      {Code: 102},
+	"end":       {Code: 107},
+	"pageup":    {Code: 104},
+	"pagedown":  {Code: 109},
+
+	// Function keys
+	"f1": {Code: 59}, "f2": {Code: 60}, "f3": {Code: 61}, "f4": {Code: 62},
+	"f5": {Code: 63}, "f6": {Code: 64}, "f7": {Code: 65}, "f8": {Code: 66},
+	"f9": {Code: 67}, "f10": {Code: 68}, "f11": {Code: 87}, "f12": {Code: 88},
+	"f13": {Code: 183}, "f14": {Code: 184}, "f15": {Code: 185}, "f16": {Code: 186},
+	"f17": {Code: 187}, "f18": {Code: 188}, "f19": {Code: 189}, "f20": {Code: 190},
+	"f21": {Code: 191}, "f22": {Code: 192}, "f23": {Code: 193}, "f24": {Code: 194},
+
+	// Modifiers
+	"cmd":         {Code: 125},
+	"lcmd":        {Code: 125},
+	"rcmd":        {Code: 126},
+	"alt":         {Code: 56},
+	"lalt":        {Code: 56},
+	"ralt":        {Code: 100},
+	"ctrl":        {Code: 29},
+	"lctrl":       {Code: 29},
+	"rctrl":       {Code: 97},
+	"control":     {Code: 29},
+	"shift":       {Code: KeyLeftShift},
+	"lshift":      {Code: KeyLeftShift},
+	"rshift":      {Code: 54},
+	"capslock":    {Code: 58},
+	"space":       {Code: KeySpace},
+	"print":       {Code: 99},
+	"printscreen": {Code: 99},
+	"insert":      {Code: 110},
+	"menu":        {Code: 127},
+
+	// Media
+	"audio_mute":     {Code: 113},
+	"audio_vol_down": {Code: 114},
+	"audio_vol_up":   {Code: 115},
+	"audio_play":     {Code: 164},
+	"audio_stop":     {Code: 166},
+	"audio_pause":    {Code: 201},
+	"audio_prev":     {Code: 165},
+	"audio_next":     {Code: 163},
+	"audio_rewind":   {Code: 168},
+	"audio_forward":  {Code: 208},
+	"audio_repeat":   {Code: 439},
+	"audio_random":   {Code: 410},
+
+	// Numpad
+	"num0": {Code: 82}, "num1": {Code: 79}, "num2": {Code: 80}, "num3": {Code: 81}, "num4": {Code: 75},
+	"num5": {Code: 76}, "num6": {Code: 77}, "num7": {Code: 71}, "num8": {Code: 72}, "num9": {Code: 73},
+	"num_lock":  {Code: 69},
+	"num.":      {Code: 83},
+	"num+":      {Code: 78},
+	"num-":      {Code: 74},
+	"num*":      {Code: 55},
+	"num/":      {Code: 98},
+	"num_clear": {Code: 355},
+	"num_enter": {Code: 96},
+	"num_equal": {Code: 117},
+
+	// Brightness / lights
+	"lights_mon_up":     {Code: 225},
+	"lights_mon_down":   {Code: 224},
+	"lights_kbd_toggle": {Code: 228},
+	"lights_kbd_up":     {Code: 230},
+	"lights_kbd_down":   {Code: 229},
+}
+
+// Characters typed by a US keyboard layout -> evdev key
+// Other layouts map some characters to different keys, this is a limitation of evdev
+var Runes = map[rune]Key{
+	' ': {Code: KeySpace}, '\n': {Code: KeyEnter}, '\t': {Code: KeyTab},
+
+	'-': {Code: 12}, '=': {Code: 13}, '[': {Code: 26}, ']': {Code: 27}, ';': {Code: 39},
+	'\'': {Code: 40}, '`': {Code: 41}, '\\': {Code: 43}, ',': {Code: 51}, '.': {Code: 52}, '/': {Code: 53},
+
+	'_': {Code: 12, Shift: true}, '+': {Code: 13, Shift: true}, '{': {Code: 26, Shift: true},
+	'}': {Code: 27, Shift: true}, ':': {Code: 39, Shift: true}, '"': {Code: 40, Shift: true},
+	'~': {Code: 41, Shift: true}, '|': {Code: 43, Shift: true}, '<': {Code: 51, Shift: true},
+	'>': {Code: 52, Shift: true}, '?': {Code: 53, Shift: true},
+
+	'!': {Code: 2, Shift: true}, '@': {Code: 3, Shift: true}, '#': {Code: 4, Shift: true},
+	'$': {Code: 5, Shift: true}, '%': {Code: 6, Shift: true}, '^': {Code: 7, Shift: true},
+	'&': {Code: 8, Shift: true}, '*': {Code: 9, Shift: true}, '(': {Code: 10, Shift: true},
+	')': {Code: 11, Shift: true},
+}
+
+func init() {
+	// Digits: KEY_1 (2) to KEY_9 (10), then KEY_0 (11)
+	for i, digit := range "1234567890" {
+		Keys[string(digit)] = Key{Code: uint16(2 + i)}
+		Runes[digit] = Key{Code: uint16(2 + i)}
+	}
+
+	// Letters follow the QWERTY rows
+	rows := []struct {
+		letters string
+		first   uint16
+	}{{"qwertyuiop", 16}, {"asdfghjkl", 30}, {"zxcvbnm", 44}}
+	for _, row := range rows {
+		for i, letter := range row.letters {
+			upper := letter - 'a' + 'A'
+			Keys[string(letter)] = Key{Code: row.first + uint16(i)}
+			Keys[string(upper)] = Key{Code: row.first + uint16(i), Shift: true}
+			Runes[letter] = Key{Code: row.first + uint16(i)}
+			Runes[upper] = Key{Code: row.first + uint16(i), Shift: true}
+		}
+	}
+}
+
+// Returns the key codes of every key in Keys, used to register the keyboard capabilities
+func KeyCodes() []uint16 {
+	seen := make(map[uint16]bool)
+	var codes []uint16
+	for _, key := range Keys {
+		if !seen[key.Code] {
+			seen[key.Code] = true
+			codes = append(codes, key.Code)
+		}
+	}
+	for _, key := range Runes {
+		if !seen[key.Code] {
+			seen[key.Code] = true
+			codes = append(codes, key.Code)
+		}
+	}
+	return codes
+}
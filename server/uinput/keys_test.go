@@ -0,0 +1,102 @@
+package uinput
+
+import (
+	"slices"
+	"testing"
+
+	Pedal "stepkeys/server/pedal"
+)
+
+func TestKeysCoverValidKeys(t *testing.T) {
+	for name := range Pedal.ValidKeys {
+		key, ok := Keys[name]
+		if !ok {
+			t.Errorf("key %q has no evdev code", name)
+		} else if key.Code == 0 {
+			t.Errorf("key %q maps to KEY_RESERVED", name)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := map[string]Key{
+		"a":      {Code: 30},
+		"A":      {Code: 30, Shift: true},
+		"q":      {Code: 16},
+		"m":      {Code: 50},
+		"Z":      {Code: 44, Shift: true},
+		"1":      {Code: 2},
+		"0":      {Code: 11},
+		"shift":  {Code: KeyLeftShift},
+		"rshift": {Code: 54},
+		"enter":  {Code: KeyEnter},
+		"f24":    {Code: 194},
+	}
+	for name, want := range keys {
+		if got := Keys[name]; got != want {
+			t.Errorf("key %q: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestRunes(t *testing.T) {
+	// Every printable ASCII character can be typed
+	for r := rune(' '); r <= '~'; r++ {
+		if _, ok := Runes[r]; !ok {
+			t.Errorf("rune %q has no key", r)
+		}
+	}
+
+	runes := map[rune]Key{
+		'a':  {Code: 30},
+		'A':  {Code: 30, Shift: true},
+		'p':  {Code: 25},
+		'P':  {Code: 25, Shift: true},
+		'1':  {Code: 2},
+		'!':  {Code: 2, Shift: true},
+		'0':  {Code: 11},
+		')':  {Code: 11, Shift: true},
+		'-':  {Code: 12},
+		'_':  {Code: 12, Shift: true},
+		';':  {Code: 39},
+		':':  {Code: 39, Shift: true},
+		'\'': {Code: 40},
+		'"':  {Code: 40, Shift: true},
+		'/':  {Code: 53},
+		'?':  {Code: 53, Shift: true},
+		' ':  {Code: KeySpace},
+		'\n': {Code: KeyEnter},
+		'\t': {Code: KeyTab},
+	}
+	for r, want := range runes {
+		if got := Runes[r]; got != want {
+			t.Errorf("rune %q: got %+v, want %+v", r, got, want)
+		}
+	}
+
+	// Characters outside the US layout are not guessed
+	for _, r := range "éß€ü" {
+		if key, ok := Runes[r]; ok {
+			t.Errorf("rune %q: got %+v", r, key)
+		}
+	}
+}
+
+func TestKeyCodes(t *testing.T) {
+	codes := KeyCodes()
+	for name, key := range Keys {
+		if !slices.Contains(codes, key.Code) {
+			t.Errorf("code of key %q is not registered", name)
+		}
+	}
+	for r, key := range Runes {
+		if !slices.Contains(codes, key.Code) {
+			t.Errorf("code of rune %q is not registered", r)
+		}
+	}
+
+	slices.Sort(codes)
+	if len(slices.Compact(codes)) != len(KeyCodes()) {
+		t.Error("duplicate codes")
+	}
+}
@@ -0,0 +1,53 @@
+package uinput
+
+import (
+	"errors"
+)
+
+// Linux input event types and codes (linux/input-event-codes.h)
+const (
+	EvSyn uint16 = 0x00
+	EvKey uint16 = 0x01
+	EvRel uint16 = 0x02
+	EvAbs uint16 = 0x03
+
+	SynReport uint16 = 0
+
+	RelX      uint16 = 0x00
+	RelY      uint16 = 0x01
+	RelHWheel uint16 = 0x06
+	RelWheel  uint16 = 0x08
+
+	BtnLeft   uint16 = 0x110
+	BtnRight  uint16 = 0x111
+	BtnMiddle uint16 = 0x112
+)
+
+// Returned by Create on systems without uinput
+var ErrUnsupported = errors.New("uinput is only available on Linux")
+
+// Event is an input event sent by a virtual device
+type Event struct {
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// AbsAxis describes an absolute axis of a virtual device
+type AbsAxis struct {
+	Code uint16
+	Min  int32
+	Max  int32
+}
+
+// Capabilities of a virtual device
+type Capabilities struct {
+	Keys []uint16 // key and button codes
+	Rels []uint16 // relative axes
+	Abs  []AbsAxis
+
+	// USB IDs reported by the device, games use them to pick a controller mapping
+	// Zero values report a generic StepKeys device
+	Vendor  uint16
+	Product uint16
+}
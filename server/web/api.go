@@ -2,14 +2,19 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/getlantern/systray"
 
 	Config "stepkeys/server/config"
+	Handler "stepkeys/server/handler"
 	Log "stepkeys/server/logging"
+	OBSClient "stepkeys/server/obs"
 	. "stepkeys/server/pedal"
 	Pedal "stepkeys/server/pedal"
 	Updater "stepkeys/server/updater"
@@ -59,17 +64,25 @@ func getPedals(w http.ResponseWriter, _ *http.Request) {
 }
 
 // @Summary      Update all pedals
-// @Description  Replaces the current pedal configuration entirely.
+// @Description  Replaces the current pedal configuration entirely. Command and launchApp pedals, openUrl pedals with a URL other than http, https or mailto, and script pedals with permissions can only be added or changed in pedals.json, the ones already loaded are accepted unchanged.
 // @Tags         pedals
 // @Accept       json
 // @Produce      json
 // @Param        pedals  body  PedalMap  true  "New pedal configuration"
 // @Success      200     {object} PedalMap
 // @Failure      400     {object} ErrorResponse
+// @Failure      403     {object} ErrorResponse
+// @Failure      415     {object} ErrorResponse
 // @Router       /api/pedals [post]
 func updatePedals(w http.ResponseWriter, r *http.Request) {
 	var newConfig PedalMap
 
+	// Forms of other sites cannot send JSON without a CORS preflight, which is never allowed
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentType)); mediaType != contentTypeJson {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+contentTypeJson)
+		return
+	}
+
 	if err := json.NewDecoder(r.Body).Decode(&newConfig); err != nil {
 		writeJSONError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
@@ -81,12 +94,40 @@ func updatePedals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkPrivilegedActions(newConfig); err != nil {
+		writeJSONError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
 	Config.SetPedalMap(newConfig)
 
 	w.Header().Set(contentType, contentTypeJson)
 	_ = json.NewEncoder(w).Encode(newConfig)
 }
 
+// Helper: reject privileged actions (commands, apps, non-web URLs, scripts with permissions) that are not in the current pedal map
+// They can only be added by editing pedals.json, so an API client cannot make StepKeys run arbitrary programs
+// Unchanged ones are accepted, as the GUI sends the full map back
+func checkPrivilegedActions(newConfig PedalMap) error {
+	var current []string
+	for _, action := range PrivilegedActions(Config.GetPedalMap()) {
+		data, _ := json.Marshal(action)
+		current = append(current, string(data))
+	}
+
+	for _, action := range PrivilegedActions(newConfig) {
+		data, _ := json.Marshal(action)
+		if slices.Contains(current, string(data)) {
+			continue
+		}
+		if action.Mode == OpenURL {
+			return fmt.Errorf("openUrl pedals can only open http, https and mailto URLs, others can only be added or changed in pedals.json")
+		}
+		return fmt.Errorf("%s pedals (and scripts with permissions) can only be added or changed in pedals.json", action.Mode)
+	}
+	return nil
+}
+
 // @Summary      Get enabled state
 // @Description  Returns whether StepKeys is enabled or disabled.
 // @Tags         settings
@@ -213,6 +254,65 @@ func getValidKeys(w http.ResponseWriter, _ *http.Request) {
 	_ = json.NewEncoder(w).Encode(keys)
 }
 
+// @Summary      Get cycle pedal progress
+// @Description  Returns the index of the next action of every cycle pedal that was pressed since the last reset.
+// @Tags         pedals
+// @Produce      json
+// @Success      200 {object} map[string]int
+// @Router       /api/cycles [get]
+func getCycles(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, contentTypeJson)
+	_ = json.NewEncoder(w).Encode(Handler.GetCycleIndexes())
+}
+
+// @Summary      Reset cycle pedals
+// @Description  Restarts a cycle pedal from its first action. Without the pedal parameter, every cycle pedal is reset. Returns 400 for an empty or invalid pedal ID (0-127) and 404 if the pedal has no cycle action.
+// @Tags         pedals
+// @Produce      json
+// @Param        pedal query string false "Pedal ID (0-127)"
+// @Success      200 {object} map[string]int
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Router       /api/cycles/reset [post]
+func resetCycles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("pedal") {
+		Handler.ResetAllCycles()
+	} else if err := Handler.ResetCycle(query.Get("pedal")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, Handler.ErrNotCyclePedal) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set(contentType, contentTypeJson)
+	_ = json.NewEncoder(w).Encode(Handler.GetCycleIndexes())
+}
+
+// @Summary      Get held keys
+// @Description  Returns the keys that are currently held down and the IDs of the pedals holding them. A key is released when its last owner lets go.
+// @Tags         pedals
+// @Produce      json
+// @Success      200 {object} map[string][]int
+// @Router       /api/held-keys [get]
+func getHeldKeys(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, contentTypeJson)
+	_ = json.NewEncoder(w).Encode(Handler.GetHeldKeys())
+}
+
+// @Summary      Get OBS connection status
+// @Description  Returns whether the obs-websocket connection is configured and connected, and the reason of the last failure.
+// @Tags         additional
+// @Produce      json
+// @Success      200 {object} obs.Status
+// @Router       /api/obs [get]
+func getOBSStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, contentTypeJson)
+	_ = json.NewEncoder(w).Encode(OBSClient.GetStatus())
+}
+
 // Registers all API routes
 func RegisterAPI() {
 	http.HandleFunc("/api/pedals", func(w http.ResponseWriter, r *http.Request) {
@@ -288,10 +388,43 @@ func RegisterAPI() {
 		getValidKeys(w, r)
 	})
 
+	http.HandleFunc("/api/cycles", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, methodNotAllowed)
+			return
+		}
+		getCycles(w, r)
+	})
+
+	http.HandleFunc("/api/cycles/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, http.StatusMethodNotAllowed, methodNotAllowed)
+			return
+		}
+		resetCycles(w, r)
+	})
+
+	http.HandleFunc("/api/held-keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, methodNotAllowed)
+			return
+		}
+		getHeldKeys(w, r)
+	})
+
+	http.HandleFunc("/api/obs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, methodNotAllowed)
+			return
+		}
+		getOBSStatus(w, r)
+	})
+
 	// WebSocket endpoints
 	http.HandleFunc("/ws/logs", Log.LogsWebSocketHandler)
 	http.HandleFunc("/ws/settings", Config.SettingsWebSocketHandler)
 	http.HandleFunc("/ws/pedals", Config.PedalWebSocketHandler)
+	http.HandleFunc("/ws/state", Config.StateWebSocketHandler)
 
 	Log.WriteToLogFile("API routes registered.")
 }
@@ -0,0 +1,82 @@
+package x11
+
+import (
+	"fmt"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// Modifier masks of the key event state
+const (
+	maskShift   uint16 = xproto.ModMaskShift
+	maskControl uint16 = xproto.ModMaskControl
+	maskAlt     uint16 = xproto.ModMask1
+	maskSuper   uint16 = xproto.ModMask4
+)
+
+// Modifier key names -> state mask, used for combos
+var modifierMasks = map[string]uint16{
+	"shift": maskShift, "lshift": maskShift, "rshift": maskShift,
+	"ctrl": maskControl, "lctrl": maskControl, "rctrl": maskControl, "control": maskControl,
+	"alt": maskAlt, "lalt": maskAlt, "ralt": maskAlt,
+	"cmd": maskSuper, "lcmd": maskSuper, "rcmd": maskSuper,
+}
+
+// StepKeys key names (see pedal.ValidKeys) -> X keysym
+// Letters and digits are added in init
+var keysyms = map[string]xproto.Keysym{
+	"backspace": 0xff08, "delete": 0xffff, "enter": 0xff0d, "tab": 0xff09,
+	"esc": 0xff1b, "escape": 0xff1b,
+	"up": 0xff52, "down": 0xff54, "left": 0xff51, "right": 0xff53,
+	"home": 0xff50, "end": 0xff57, "pageup": 0xff55, "pagedown": 0xff56,
+
+	"cmd": 0xffeb, "lcmd": 0xffeb, "rcmd": 0xffec,
+	"alt": 0xffe9, "lalt": 0xffe9, "ralt": 0xffea,
+	"ctrl": 0xffe3, "lctrl": 0xffe3, "rctrl": 0xffe4, "control": 0xffe3,
+	"shift": 0xffe1, "lshift": 0xffe1, "rshift": 0xffe2,
+	"capslock": 0xffe5, "space": 0x20, "print": 0xff61, "printscreen": 0xff61,
+	"insert": 0xff63, "menu": 0xff67,
+
+	"audio_mute": 0x1008ff12, "audio_vol_down": 0x1008ff11, "audio_vol_up": 0x1008ff13,
+	"audio_play": 0x1008ff14, "audio_stop": 0x1008ff15, "audio_pause": 0x1008ff31,
+	"audio_prev": 0x1008ff16, "audio_next": 0x1008ff17, "audio_rewind": 0x1008ff3e,
+	"audio_forward": 0x1008ff97, "audio_repeat": 0x1008ff98, "audio_random": 0x1008ff99,
+
+	"num_lock": 0xff7f, "num.": 0xffae, "num+": 0xffab, "num-": 0xffad, "num*": 0xffaa,
+	"num/": 0xffaf, "num_clear": 0xff0b, "num_enter": 0xff8d, "num_equal": 0xffbd,
+
+	"lights_mon_up": 0x1008ff02, "lights_mon_down": 0x1008ff03,
+	"lights_kbd_toggle": 0x1008ff04, "lights_kbd_up": 0x1008ff05, "lights_kbd_down": 0x1008ff06,
+}
+
+func init() {
+	for i := range 24 {
+		keysyms[fmt.Sprintf("f%d", i+1)] = xproto.Keysym(0xffbe + i) // F1 to F24
+	}
+	for i := range 10 {
+		keysyms[fmt.Sprintf("num%d", i)] = xproto.Keysym(0xffb0 + i) // KP_0 to KP_9
+		keysyms[fmt.Sprintf("%d", i)] = xproto.Keysym('0' + i)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		keysyms[string(c)] = xproto.Keysym(c)
+	}
+}
+
+// Helper: keycode and extra state of a key, capital letters are typed with shift
+func keycode(key string) (xproto.Keycode, uint16, error) {
+	var state uint16
+	if len(key) == 1 && key[0] >= 'A' && key[0] <= 'Z' {
+		key = string(key[0] - 'A' + 'a')
+		state = maskShift
+	}
+
+	sym, ok := keysyms[key]
+	if !ok {
+		return 0, 0, fmt.Errorf("key %q has no X keysym", key)
+	}
+	code, ok := keymap[sym]
+	if !ok {
+		return 0, 0, fmt.Errorf("key %q is not on the keyboard mapping", key)
+	}
+	return code, state, nil
+}
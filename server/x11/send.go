@@ -0,0 +1,146 @@
+package x11
+
+import (
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Time the window manager gets to switch focus
+const focusDelay = 50 * time.Millisecond
+
+// Send keys to a window with synthetic key events, without changing the focus
+// combo taps the keys together (the last key is the main key), otherwise they are tapped one after another
+// Some applications ignore synthetic events, use WithFocus for them
+func SendKeys(window xproto.Window, keys []string, combo bool) error {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	err := sendKeys(window, keys, combo)
+	if err != nil {
+		disconnect()
+	}
+	return err
+}
+
+func sendKeys(window xproto.Window, keys []string, combo bool) error {
+	if err := connect(); err != nil {
+		return err
+	}
+
+	// Check every key first, so nothing is sent if one of them fails
+	for _, key := range keys {
+		if _, _, err := keycode(key); err != nil {
+			return err
+		}
+	}
+
+	if !combo {
+		for _, key := range keys {
+			if err := tapKey(window, key, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Modifiers of a combo are sent as the state of the main key event
+	var state uint16
+	for _, key := range keys[:len(keys)-1] {
+		mask, ok := modifierMasks[key]
+		if !ok {
+			// Not a modifier, tap it before the main key like robotgo would press it
+			if err := tapKey(window, key, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		state |= mask
+	}
+	return tapKey(window, keys[len(keys)-1], state)
+}
+
+// Helper: send a key press and release event
+func tapKey(window xproto.Window, key string, state uint16) error {
+	code, extra, err := keycode(key)
+	if err != nil {
+		return err
+	}
+
+	event := xproto.KeyPressEvent{
+		Detail:     code,
+		Time:       xproto.TimeCurrentTime,
+		Root:       root,
+		Event:      window,
+		Child:      xproto.WindowNone,
+		State:      state | extra,
+		SameScreen: true,
+	}
+	if err := xproto.SendEventChecked(conn, true, window, xproto.EventMaskKeyPress, string(event.Bytes())).Check(); err != nil {
+		return err
+	}
+
+	release := xproto.KeyReleaseEvent(event)
+	return xproto.SendEventChecked(conn, true, window, xproto.EventMaskKeyRelease, string(release.Bytes())).Check()
+}
+
+// Focus a window, run inject and give the focus back to the previously active window
+// Used for applications that ignore synthetic events
+func WithFocus(window xproto.Window, inject func()) error {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	previous, err := activeWindow()
+	if err != nil {
+		disconnect()
+		return err
+	}
+
+	if err := activate(window); err != nil {
+		disconnect()
+		return err
+	}
+	time.Sleep(focusDelay)
+
+	inject()
+
+	if previous != xproto.WindowNone && previous != window {
+		if err := activate(previous); err != nil {
+			disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: the window the window manager considers active (EWMH)
+func activeWindow() (xproto.Window, error) {
+	value, err := property(root, "_NET_ACTIVE_WINDOW")
+	if err != nil || len(value) < 4 {
+		return xproto.WindowNone, err
+	}
+	return xproto.Window(xgb.Get32(value)), nil
+}
+
+// Helper: ask the window manager to activate a window (EWMH)
+func activate(window xproto.Window) error {
+	activeAtom, err := atom("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return err
+	}
+
+	// Source indication 2: request from a pager, window managers do not refuse it
+	event := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: window,
+		Type:   activeAtom,
+		Data:   xproto.ClientMessageDataUnionData32New([]uint32{2, uint32(xproto.TimeCurrentTime), 0, 0, 0}),
+	}
+	mask := uint32(xproto.EventMaskSubstructureRedirect | xproto.EventMaskSubstructureNotify)
+	return xproto.SendEventChecked(conn, false, root, mask, string(event.Bytes())).Check()
+}
@@ -0,0 +1,159 @@
+package x11
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Connection to the X server, opened on first use
+// Guarded by connMu, a broken connection is dropped and reopened by the next call
+var (
+	conn   *xgb.Conn
+	root   xproto.Window
+	keymap map[xproto.Keysym]xproto.Keycode
+	connMu sync.Mutex
+)
+
+// Returned when no window matches
+var ErrNoWindow = errors.New("no matching window")
+
+// Helper: open the connection and load the keyboard mapping
+func connect() error {
+	if conn != nil {
+		return nil
+	}
+
+	c, err := xgb.NewConn()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the X server (X11 session required): %w", err)
+	}
+
+	setup := xproto.Setup(c)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	mapping, err := xproto.GetKeyboardMapping(c, setup.MinKeycode, count).Reply()
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	// Keysym -> first keycode producing it (without modifiers)
+	keymap = make(map[xproto.Keysym]xproto.Keycode)
+	perKeycode := int(mapping.KeysymsPerKeycode)
+	for i := range int(count) {
+		for j := range perKeycode {
+			sym := mapping.Keysyms[i*perKeycode+j]
+			if _, ok := keymap[sym]; !ok && sym != 0 {
+				keymap[sym] = setup.MinKeycode + xproto.Keycode(i)
+			}
+		}
+	}
+
+	conn = c
+	root = setup.DefaultScreen(c).Root
+	return nil
+}
+
+// Helper: drop the connection after an error, the next call reconnects
+func disconnect() {
+	if conn != nil {
+		conn.Close()
+		conn = nil
+	}
+}
+
+// Helper: look up an atom by name
+func atom(name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
+// Helper: read a window property, nil if it is not set
+func property(window xproto.Window, name string) ([]byte, error) {
+	prop, err := atom(name)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := xproto.GetProperty(conn, false, window, prop, xproto.GetPropertyTypeAny, 0, 1<<16).Reply()
+	if err != nil {
+		return nil, err
+	}
+	return reply.Value, nil
+}
+
+// Helper: the top-level windows managed by the window manager (EWMH)
+func clientWindows() ([]xproto.Window, error) {
+	value, err := property(root, "_NET_CLIENT_LIST")
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]xproto.Window, len(value)/4)
+	for i := range windows {
+		windows[i] = xproto.Window(xgb.Get32(value[i*4:]))
+	}
+	return windows, nil
+}
+
+// Find the first window whose WM_CLASS (instance or class name) and title contain the given strings
+// Matching is case-insensitive, empty strings match every window
+func FindWindow(class, title string) (xproto.Window, error) {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	window, err := findWindow(strings.ToLower(class), strings.ToLower(title))
+	if err != nil && !errors.Is(err, ErrNoWindow) {
+		disconnect()
+	}
+	return window, err
+}
+
+func findWindow(class, title string) (xproto.Window, error) {
+	if err := connect(); err != nil {
+		return 0, err
+	}
+
+	windows, err := clientWindows()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, window := range windows {
+		if class != "" {
+			// WM_CLASS holds the instance and the class name, separated by a null byte
+			value, err := property(window, "WM_CLASS")
+			if err != nil {
+				return 0, err
+			}
+			if !strings.Contains(strings.ToLower(string(value)), class) {
+				continue
+			}
+		}
+
+		if title != "" {
+			value, err := property(window, "_NET_WM_NAME")
+			if err != nil {
+				return 0, err
+			}
+			if len(value) == 0 {
+				if value, err = property(window, "WM_NAME"); err != nil {
+					return 0, err
+				}
+			}
+			if !strings.Contains(strings.ToLower(string(value)), title) {
+				continue
+			}
+		}
+
+		return window, nil
+	}
+
+	return 0, ErrNoWindow
+}
@@ -0,0 +1,228 @@
+package x11
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+func TestKeycode(t *testing.T) {
+	saved := keymap
+	t.Cleanup(func() { keymap = saved })
+	keymap = map[xproto.Keysym]xproto.Keycode{'a': 38, 0xffe1: 50, 0xffbe: 67}
+
+	keys := []struct {
+		key   string
+		code  xproto.Keycode
+		state uint16
+	}{
+		{"a", 38, 0},
+		{"A", 38, maskShift}, // Capitals are typed with shift
+		{"shift", 50, 0},
+		{"f1", 67, 0},
+	}
+	for _, k := range keys {
+		code, state, err := keycode(k.key)
+		if err != nil || code != k.code || state != k.state {
+			t.Errorf("key %q: got %d, %#x, %v, want %d, %#x", k.key, code, state, err, k.code, k.state)
+		}
+	}
+
+	// Unknown names and keys missing from the keyboard mapping
+	for _, key := range []string{"nope", "b", "f13"} {
+		if _, _, err := keycode(key); err == nil {
+			t.Errorf("key %q: no error", key)
+		}
+	}
+}
+
+func TestModifierKeysyms(t *testing.T) {
+	for key := range modifierMasks {
+		if _, ok := keysyms[key]; !ok {
+			t.Errorf("modifier %q has no keysym", key)
+		}
+	}
+}
+
+// Helper: connect to the X server of DISPLAY, skips the test without one
+func connectDisplay(t *testing.T) *xgb.Conn {
+	t.Helper()
+
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY not set")
+	}
+	c, err := xgb.NewConn()
+	if err != nil {
+		t.Skipf("cannot connect to the X server: %v", err)
+	}
+	t.Cleanup(c.Close)
+
+	// The package connection is opened by the test as well
+	t.Cleanup(func() {
+		connMu.Lock()
+		defer connMu.Unlock()
+		disconnect()
+	})
+	return c
+}
+
+// Helper: create a top-level window listening to key events
+// Without a window manager (eg. Xvfb) it is added to _NET_CLIENT_LIST by the test
+func createWindow(t *testing.T, c *xgb.Conn, class, title string) xproto.Window {
+	t.Helper()
+
+	screen := xproto.Setup(c).DefaultScreen(c)
+	window, err := xproto.NewWindowId(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = xproto.CreateWindowChecked(c, screen.RootDepth, window, screen.Root, 0, 0, 100, 100, 0,
+		xproto.WindowClassInputOutput, screen.RootVisual,
+		xproto.CwEventMask, []uint32{xproto.EventMaskKeyPress | xproto.EventMaskKeyRelease}).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { xproto.DestroyWindow(c, window) })
+
+	setString(t, c, window, xproto.AtomWmClass, "stepkeys-test\x00"+class+"\x00")
+	setString(t, c, window, xproto.AtomWmName, title)
+	if err := xproto.MapWindowChecked(c, window).Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	listAtom, err := xproto.InternAtom(c, false, uint16(len("_NET_CLIENT_LIST")), "_NET_CLIENT_LIST").Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := xproto.GetProperty(c, false, screen.Root, listAtom.Atom, xproto.AtomWindow, 0, 0).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Format == 0 {
+		value := make([]byte, 4)
+		xgb.Put32(value, uint32(window))
+		err := xproto.ChangePropertyChecked(c, xproto.PropModeReplace, screen.Root, listAtom.Atom,
+			xproto.AtomWindow, 32, 1, value).Check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { xproto.DeleteProperty(c, screen.Root, listAtom.Atom) })
+	}
+	return window
+}
+
+// Helper: set a string property of a window
+func setString(t *testing.T, c *xgb.Conn, window xproto.Window, property xproto.Atom, value string) {
+	t.Helper()
+	err := xproto.ChangePropertyChecked(c, xproto.PropModeReplace, window, property,
+		xproto.AtomString, 8, uint32(len(value)), []byte(value)).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Helper: wait for the next key event of the test connection
+func nextKeyEvent(t *testing.T, c *xgb.Conn) xgb.Event {
+	t.Helper()
+
+	events := make(chan xgb.Event, 1)
+	go func() {
+		for {
+			event, err := c.WaitForEvent()
+			if event == nil && err == nil {
+				close(events)
+				return
+			}
+			switch event.(type) {
+			case xproto.KeyPressEvent, xproto.KeyReleaseEvent:
+				events <- event
+				return
+			}
+		}
+	}()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("X connection closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a key event")
+		return nil
+	}
+}
+
+func TestFindWindowAndSendKeys(t *testing.T) {
+	c := connectDisplay(t)
+	title := fmt.Sprintf("StepKeys test window %d", os.Getpid())
+	window := createWindow(t, c, "StepKeysTest", title)
+
+	// A window manager adds the window to its client list once it manages it
+	var found xproto.Window
+	var err error
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		found, err = FindWindow("stepkeystest", title)
+		if err == nil || !errors.Is(err, ErrNoWindow) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil || found != window {
+		t.Fatalf("FindWindow: got %d, %v, want %d", found, err, window)
+	}
+
+	if _, err := FindWindow("stepkeystest", title+" (other)"); !errors.Is(err, ErrNoWindow) {
+		t.Errorf("FindWindow with another title: got error %v, want ErrNoWindow", err)
+	}
+
+	// Combos send the modifiers as the state of the main key
+	if err := SendKeys(window, []string{"ctrl", "shift", "a"}, true); err != nil {
+		t.Fatal(err)
+	}
+	connMu.Lock()
+	code, _, err := keycode("a")
+	connMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	press, ok := nextKeyEvent(t, c).(xproto.KeyPressEvent)
+	if !ok || press.Detail != code || press.State != maskControl|maskShift || press.Event != window {
+		t.Errorf("combo press: got %+v", press)
+	}
+	if release, ok := nextKeyEvent(t, c).(xproto.KeyReleaseEvent); !ok || release.Detail != code {
+		t.Errorf("combo release: got %+v", release)
+	}
+
+	// Sequences tap each key
+	if err := SendKeys(window, []string{"a", "A"}, false); err != nil {
+		t.Fatal(err)
+	}
+	states := []uint16{0, 0, maskShift, maskShift}
+	for i, state := range states {
+		event := nextKeyEvent(t, c)
+		var detail xproto.Keycode
+		var got uint16
+		switch e := event.(type) {
+		case xproto.KeyPressEvent:
+			detail, got = e.Detail, e.State
+		case xproto.KeyReleaseEvent:
+			detail, got = e.Detail, e.State
+		}
+		if detail != code || got != state {
+			t.Errorf("sequence event %d: got %+v, want state %#x", i, event, state)
+		}
+	}
+
+	// Unknown keys fail before anything is sent
+	if err := SendKeys(window, []string{"a", "nope"}, false); err == nil {
+		t.Error("unknown key: no error")
+	}
+}